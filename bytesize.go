@@ -0,0 +1,48 @@
+package goload
+
+import (
+	"reflect"
+
+	"github.com/asppj/goload/internal/conv"
+)
+
+// UnitTag is the tag of the unit of int fields, `unit:"bytes"` parses and
+// exports them as a ByteSize.
+const (
+	UnitTag   = "unit"
+	UnitBytes = conv.UnitBytes
+)
+
+// ByteSize is a size in bytes in a human form: 512, 512k, 10MB, 1.5GiB.
+// KB, MB... are powers of 1000, KiB, MiB... and the single letters k, m...
+// powers of 1024; units are case-insensitive.
+type ByteSize = conv.ByteSize
+
+const (
+	B  = conv.B
+	KB = conv.KB
+	MB = conv.MB
+	GB = conv.GB
+	TB = conv.TB
+	PB = conv.PB
+
+	KiB = conv.KiB
+	MiB = conv.MiB
+	GiB = conv.GiB
+	TiB = conv.TiB
+	PiB = conv.PiB
+)
+
+// ParseByteSize parses a size like 10MB or 1.5GiB, a number without unit is
+// in bytes. Fractions of bytes are rounded.
+func ParseByteSize(s string) (ByteSize, error) {
+	return conv.ParseByteSize(s)
+}
+
+// parseByteSize parses s into the int v with the unit tag bytes.
+func parseByteSize(v reflect.Value, s string) error {
+	if err := conv.SetByteSize(v, s); err != nil {
+		return parseError(s, v.Type(), err)
+	}
+	return nil
+}
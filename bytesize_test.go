@@ -0,0 +1,84 @@
+package goload
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	cases := map[string]ByteSize{
+		"0":      0,
+		"512":    512,
+		"512B":   512,
+		"512k":   512 * KiB,
+		"10MB":   10 * MB,
+		"10 mb":  10 * MB,
+		"1.5GiB": 1536 * MiB,
+		"2Gi":    2 * GiB,
+		"1.5KB":  1500,
+		"1e3":    1000,
+	}
+	for s, want := range cases {
+		if size, err := ParseByteSize(s); err != nil || size != want {
+			t.Errorf("ParseByteSize(%q) = %v, %v; want %v", s, size, err, want)
+		}
+	}
+	for _, s := range []string{"", "MB", "10XB", "1.5.5MB", "8EiB", "9000000000G"} {
+		if size, err := ParseByteSize(s); err == nil {
+			t.Errorf("ParseByteSize(%q) should fail: %v", s, size)
+		}
+	}
+}
+func TestByteSizeString(t *testing.T) {
+	cases := map[ByteSize]string{
+		0:            "0B",
+		1000:         "1KB",
+		1023:         "1023B",
+		10 * MiB:     "10MiB",
+		10 * MB:      "10MB",
+		1536 * MiB:   "1536MiB",
+		-2 * KiB:     "-2KiB",
+		3 * TiB:      "3TiB",
+		1_500_000:    "1500KB",
+		10*MiB + 512: "10486272B",
+	}
+	for size, want := range cases {
+		if got := size.String(); got != want {
+			t.Errorf("%d.String() = %v, want %v", int64(size), got, want)
+		}
+		if parsed, err := ParseByteSize(want); err != nil || parsed != size {
+			t.Errorf("round trip of %v: %v, %v", want, parsed, err)
+		}
+	}
+}
+
+type sizeConf struct {
+	MaxBody int64    `default:"10MB" unit:"bytes"`
+	Buffer  ByteSize `default:"512k"`
+	Cache   *uint32  `default:"1GiB" unit:"bytes"`
+}
+
+func TestLoadStructByteSize(t *testing.T) {
+	c := sizeConf{}
+	if err := LoadStruct(&c, "default"); err != nil {
+		t.Fatal(err)
+	}
+	if c.MaxBody != 10_000_000 || c.Buffer != 512*KiB || c.Cache == nil || *c.Cache != 1<<30 {
+		t.Errorf("%+v", c)
+	}
+}
+
+func TestLoadStructByteSizeUint(t *testing.T) {
+	c := struct {
+		Quota uint64 `default:"16383PiB" unit:"bytes"`
+	}{}
+	if err := LoadStruct(&c, "default"); err != nil || c.Quota != (1<<14-1)<<50 {
+		t.Errorf("%+v, %v", c, err)
+	}
+	var quota uint64
+	for _, s := range []string{"-1k", "16384PiB"} {
+		if err := parseByteSize(reflect.ValueOf(&quota).Elem(), s); err == nil {
+			t.Errorf("%v should be out of range for a uint64", s)
+		}
+	}
+}
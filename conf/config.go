@@ -1,20 +1,22 @@
 package conf
 
+import "net"
+
 type CommonConf struct {
 	Mode    string `json:"mode" default:"dev" option:"dev,prod"`
 	AppName string `json:"appName" default:"commonApp" valid:"required"`
 }
 
 type LocalConf struct {
-	L       []Logger          `json:"l" desc:"日志" default:"0,1,2,3" option:"default"`
+	L       []Logger          `json:"l" desc:"日志" default:"0,1,2,3" option:"default" merge:"bykey=name"`
 	Log     []Logger          `json:"log_Map2" desc:"日志" default:"0,1,2,3" option:"default"`
 	Log2    []*Logger         `json:"log_Map3" desc:"日志" default:"0,1,2,3" option:"default"`
-	WhiteIP []string          `json:"white_IP" desc:"白名单" default:"127.0.0.1,10.0.0.1,198.0.0.1" option:"0,1,2,3"`
+	WhiteIP []net.IP          `json:"white_IP" desc:"白名单" default:"127.0.0.1,10.0.0.1,198.0.0.1" merge:"union"`
 	LogMap  map[string]Logger `json:"logMap" desc:"日志" default:"default,app,server" option:"default"`
 	LogMap2 map[int]Logger    `json:"logMap2" desc:"日志" default:"1,2,3" option:"default"`
 	cfgFile string            `default:"cfgFile" option:"" valid:"required"   desc:"配置文件地址"` // 不支持这种不可导出字段
 	*CommonConf
-	AppName string `json:"appName" default:"demoApp" desc:"app名字" valid:"option(testDemo|devDemo)"`
+	AppName string `json:"appName" default:"devDemo" desc:"app名字" valid:"option(testDemo|devDemo)"`
 	Redis   *Redis `json:"redis" desc:"redis配置"`
 }
 
@@ -0,0 +1,169 @@
+package conv
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// UnitBytes is the value of the unit tag of the ints parsed and exported as
+// a ByteSize.
+const UnitBytes = "bytes"
+
+// ByteSize is a size in bytes in a human form: 512, 512k, 10MB, 1.5GiB.
+// KB, MB... are powers of 1000, KiB, MiB... and the single letters k, m...
+// powers of 1024; units are case-insensitive.
+type ByteSize int64
+
+const (
+	B  ByteSize = 1
+	KB          = 1000 * B
+	MB          = 1000 * KB
+	GB          = 1000 * MB
+	TB          = 1000 * GB
+	PB          = 1000 * TB
+
+	KiB = 1024 * B
+	MiB = 1024 * KiB
+	GiB = 1024 * MiB
+	TiB = 1024 * GiB
+	PiB = 1024 * TiB
+)
+
+// byteUnits are the units by lower case name.
+var byteUnits = map[string]ByteSize{
+	"": B, "b": B,
+	"kb": KB, "mb": MB, "gb": GB, "tb": TB, "pb": PB,
+	"k": KiB, "m": MiB, "g": GiB, "t": TiB, "p": PiB,
+	"ki": KiB, "mi": MiB, "gi": GiB, "ti": TiB, "pi": PiB,
+	"kib": KiB, "mib": MiB, "gib": GiB, "tib": TiB, "pib": PiB,
+}
+
+// ParseByteSize parses a size like 10MB or 1.5GiB, a number without unit is
+// in bytes. Fractions of bytes are rounded.
+func ParseByteSize(s string) (ByteSize, error) {
+	num, unit, err := splitByteSize(s)
+	if err != nil {
+		return 0, err
+	}
+	if n, err := strconv.ParseInt(num, 10, 64); err == nil {
+		if n != 0 && (n > math.MaxInt64/int64(unit) || n < math.MinInt64/int64(unit)) {
+			return 0, fmt.Errorf("byte size %q overflows", s)
+		}
+		return ByteSize(n) * unit, nil
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte size %q", s)
+	}
+	size := math.Round(f * float64(unit))
+	if size >= math.MaxInt64 || size < math.MinInt64 {
+		return 0, fmt.Errorf("byte size %q overflows", s)
+	}
+	return ByteSize(size), nil
+}
+
+// parseUintSize is ParseByteSize for the uints, up to math.MaxUint64.
+func parseUintSize(s string) (uint64, error) {
+	num, unit, err := splitByteSize(s)
+	if err != nil {
+		return 0, err
+	}
+	if n, err := strconv.ParseUint(num, 10, 64); err == nil {
+		if n > math.MaxUint64/uint64(unit) {
+			return 0, fmt.Errorf("byte size %q overflows", s)
+		}
+		return n * uint64(unit), nil
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte size %q", s)
+	}
+	size := math.Round(f * float64(unit))
+	if size >= math.MaxUint64 || size < 0 {
+		return 0, fmt.Errorf("byte size %q out of range", s)
+	}
+	return uint64(size), nil
+}
+
+// splitByteSize splits s into its number and unit.
+func splitByteSize(s string) (string, ByteSize, error) {
+	s = strings.TrimSpace(s)
+	i := strings.LastIndexAny(s, "0123456789.") + 1
+	unit, ok := byteUnits[strings.ToLower(strings.TrimSpace(s[i:]))]
+	if !ok || i == 0 {
+		return "", 0, fmt.Errorf("invalid byte size %q", s)
+	}
+	return s[:i], unit, nil
+}
+
+// SetByteSize parses s into the int or uint v with the unit tag bytes, the
+// error is not wrapped with the value and type.
+func SetByteSize(v reflect.Value, s string) error {
+	if v.CanUint() {
+		size, err := parseUintSize(s)
+		if err != nil {
+			return err
+		}
+		if v.OverflowUint(size) {
+			return errors.New("out of range")
+		}
+		v.SetUint(size)
+		return nil
+	}
+	size, err := ParseByteSize(s)
+	if err != nil {
+		return err
+	}
+	if v.OverflowInt(int64(size)) {
+		return errors.New("out of range")
+	}
+	v.SetInt(int64(size))
+	return nil
+}
+
+// String returns the size in the largest unit dividing it, binary units
+// first: 10485760 is 10MiB, 10000000 is 10MB.
+func (b ByteSize) String() string {
+	if b < 0 {
+		return "-" + FormatUint(uint64(-b)) // -MinInt64 converts to 1<<63
+	}
+	return FormatUint(uint64(b))
+}
+
+// FormatUint formats n like ByteSize.String, for the uints beyond
+// math.MaxInt64.
+func FormatUint(n uint64) string {
+	if n == 0 {
+		return "0B"
+	}
+	units := []struct {
+		size ByteSize
+		name string
+	}{
+		{PiB, "PiB"}, {TiB, "TiB"}, {GiB, "GiB"}, {MiB, "MiB"}, {KiB, "KiB"},
+		{PB, "PB"}, {TB, "TB"}, {GB, "GB"}, {MB, "MB"}, {KB, "KB"},
+	}
+	for _, unit := range units {
+		if n%uint64(unit.size) == 0 {
+			return strconv.FormatUint(n/uint64(unit.size), 10) + unit.name
+		}
+	}
+	return strconv.FormatUint(n, 10) + "B"
+}
+
+func (b ByteSize) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	size, err := ParseByteSize(string(text))
+	if err != nil {
+		return err
+	}
+	*b = size
+	return nil
+}
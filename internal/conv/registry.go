@@ -0,0 +1,55 @@
+package conv
+
+import (
+	"reflect"
+	"sync"
+)
+
+// registry holds the decoders and encoders of the types set from text, see
+// RegisterDecoder.
+var registry = struct {
+	sync.RWMutex
+	decoders map[reflect.Type]func(s string) (reflect.Value, error)
+	encoders map[reflect.Type]func(v reflect.Value) (string, error)
+}{
+	decoders: make(map[reflect.Type]func(s string) (reflect.Value, error)),
+	encoders: make(map[reflect.Type]func(v reflect.Value) (string, error)),
+}
+
+// RegisterDecoder registers decode to parse the values of type T, see
+// goload.RegisterDecoder.
+func RegisterDecoder[T any](decode func(s string) (T, error)) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.decoders[reflect.TypeOf((*T)(nil)).Elem()] = func(s string) (reflect.Value, error) {
+		v, err := decode(s)
+		return reflect.ValueOf(&v).Elem(), err
+	}
+}
+
+// RegisterEncoder registers encode to format the values of type T as text,
+// see goload.RegisterEncoder.
+func RegisterEncoder[T any](encode func(v T) (string, error)) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.encoders[reflect.TypeOf((*T)(nil)).Elem()] = func(v reflect.Value) (string, error) {
+		return encode(v.Interface().(T))
+	}
+}
+
+// LookupDecoder returns the decoder registered for t, the value returned is
+// of type t.
+func LookupDecoder(t reflect.Type) (func(s string) (reflect.Value, error), bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	decode, ok := registry.decoders[t]
+	return decode, ok
+}
+
+// LookupEncoder returns the encoder registered for t, v must be of type t.
+func LookupEncoder(t reflect.Type) (func(v reflect.Value) (string, error), bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	encode, ok := registry.encoders[t]
+	return encode, ok
+}
@@ -0,0 +1,47 @@
+package conv
+
+import (
+	"net"
+	"net/url"
+	"regexp"
+)
+
+func init() {
+	// types set from text without encoding.TextUnmarshaler
+	RegisterDecoder(func(s string) (url.URL, error) {
+		u, err := url.Parse(s)
+		if err != nil {
+			return url.URL{}, err
+		}
+		return *u, nil
+	})
+	RegisterEncoder(func(u url.URL) (string, error) {
+		return u.String(), nil
+	})
+	RegisterDecoder(func(s string) (net.IPNet, error) {
+		if s == "" {
+			return net.IPNet{}, nil
+		}
+		_, ipNet, err := net.ParseCIDR(s) // 10.0.0.0/8, the address is masked
+		if err != nil {
+			return net.IPNet{}, err
+		}
+		return *ipNet, nil
+	})
+	RegisterEncoder(func(ipNet net.IPNet) (string, error) {
+		if ipNet.IP == nil {
+			return "", nil
+		}
+		return ipNet.String(), nil
+	})
+	RegisterDecoder(func(s string) (regexp.Regexp, error) {
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return regexp.Regexp{}, err
+		}
+		return *re, nil
+	})
+	RegisterEncoder(func(re regexp.Regexp) (string, error) {
+		return re.String(), nil
+	})
+}
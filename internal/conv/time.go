@@ -0,0 +1,95 @@
+// Package conv holds the text formats shared by goload and pkg/parse:
+// durations with days and weeks, named time layouts, byte sizes and the
+// decoders and encoders registered for other types.
+package conv
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// timeLayouts are the layouts of the time package by name.
+var timeLayouts = map[string]string{
+	"ANSIC":       time.ANSIC,
+	"UnixDate":    time.UnixDate,
+	"RubyDate":    time.RubyDate,
+	"RFC822":      time.RFC822,
+	"RFC822Z":     time.RFC822Z,
+	"RFC850":      time.RFC850,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"Kitchen":     time.Kitchen,
+	"Stamp":       time.Stamp,
+	"StampMilli":  time.StampMilli,
+	"StampMicro":  time.StampMicro,
+	"StampNano":   time.StampNano,
+	"DateTime":    "2006-01-02 15:04:05",
+	"DateOnly":    "2006-01-02",
+	"TimeOnly":    "15:04:05",
+}
+
+// TimeLayout returns the layout of the time package named name, e.g.
+// DateOnly, or name itself; RFC3339 if name is empty.
+func TimeLayout(name string) string {
+	if name == "" {
+		return time.RFC3339
+	}
+	if layout, ok := timeLayouts[name]; ok {
+		return layout
+	}
+	return name
+}
+
+// ParseTime parses s with the layout, see TimeLayout.
+func ParseTime(s, layout string) (time.Time, error) {
+	return time.Parse(TimeLayout(layout), s)
+}
+
+// durationUnit matches a (number)(unit) pair at the start of a duration.
+var durationUnit = regexp.MustCompile(`^([0-9]*\.?[0-9]+)(ns|us|µs|μs|ms|s|m|h|d|w)`)
+
+// ParseDuration parses a duration like time.ParseDuration, with the units d
+// (24h) and w (7d) in addition, e.g. 1w2d12h or 1.5d. A number without unit
+// is in nanoseconds, as a time.Duration decoded from a number.
+func ParseDuration(s string) (time.Duration, error) {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Duration(n), nil
+	}
+	rest, sign := s, ""
+	if rest != "" && (rest[0] == '-' || rest[0] == '+') {
+		rest, sign = rest[1:], rest[:1]
+	}
+	if rest == "" {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	// rewrite d and w as hours, the other units are left to time.ParseDuration
+	std := sign
+	for rest != "" {
+		m := durationUnit.FindStringSubmatch(rest)
+		if m == nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		rest = rest[len(m[0]):]
+		if m[2] != "d" && m[2] != "w" {
+			std += m[0]
+			continue
+		}
+		n, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		if m[2] == "w" {
+			n *= 7
+		}
+		std += strconv.FormatFloat(n*24, 'f', -1, 64) + "h"
+	}
+	d, err := time.ParseDuration(std)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
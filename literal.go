@@ -0,0 +1,44 @@
+package goload
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// literalDecoders decode the default tags in the literal form by prefix,
+// e.g. `default:"json:[{\"name\":\"a\"}]"` or `default:"yaml:{a: 1, b: 2}"`.
+var literalDecoders = map[string]func(data []byte, v interface{}) error{
+	"json:": json.Unmarshal,
+	"yaml:": yaml.Unmarshal,
+}
+
+func isLiteral(s string) bool {
+	for prefix := range literalDecoders {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeLiteral decodes the default tag in the literal form into v, the keys
+// are the json or yaml tags of the fields; ok is false for the other
+// defaults. The fields missing from the literal get their default tags after.
+func decodeLiteral(v reflect.Value, option *TagOption) (ok bool, err error) {
+	if option == nil || option.TagValue == nil {
+		return false, nil
+	}
+	for prefix, decode := range literalDecoders {
+		if literal := *option.TagValue; strings.HasPrefix(literal, prefix) {
+			if err := decode([]byte(strings.TrimPrefix(literal, prefix)), v.Addr().Interface()); err != nil {
+				return true, fmt.Errorf("invalid %v literal %q: %w", strings.TrimSuffix(prefix, ":"), literal, err)
+			}
+			return true, nil
+		}
+	}
+	return false, nil
+}
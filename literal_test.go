@@ -0,0 +1,62 @@
+package goload
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type literalServer struct {
+	Name string `json:"name" yaml:"name"`
+	Port int    `json:"port" yaml:"port" default:"80"`
+}
+
+type literalConf struct {
+	Weights  map[string]int           `default:"a=1,b=2,c"`
+	Timeouts map[string]time.Duration `default:"read=5s,write=1m"`
+	Limits   map[string]int64         `default:"body=10MB" unit:"bytes"`
+	Servers  []literalServer          `default:"json:[{\"name\":\"a\",\"port\":8080},{\"name\":\"b\"}]"`
+	Labels   map[string][]string      `default:"yaml:{env: [prod, eu], team: [core]}"`
+	Main     literalServer            `default:"yaml:{name: main}"`
+	Ports    []int                    `default:"json:[80,443]"`
+	Kept     map[string]int           `default:"x=1"`
+}
+
+func TestLoadStructLiteral(t *testing.T) {
+	c := literalConf{Kept: map[string]int{"y": 2}}
+	if err := LoadStruct(&c, "default"); err != nil {
+		t.Fatal(err)
+	}
+	want := literalConf{
+		Weights:  map[string]int{"a": 1, "b": 2, "c": 0},
+		Timeouts: map[string]time.Duration{"read": 5 * time.Second, "write": time.Minute},
+		Limits:   map[string]int64{"body": 10_000_000},
+		Servers:  []literalServer{{Name: "a", Port: 8080}, {Name: "b", Port: 80}},
+		Labels:   map[string][]string{"env": {"prod", "eu"}, "team": {"core"}},
+		Main:     literalServer{Name: "main", Port: 80},
+		Ports:    []int{80, 443},
+		Kept:     map[string]int{"y": 2},
+	}
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("got %+v\nwant %+v", c, want)
+	}
+}
+
+func TestLoadStructLiteralInvalid(t *testing.T) {
+	cases := []interface{}{
+		&struct {
+			M map[string]int `default:"a=x"`
+		}{},
+		&struct {
+			M map[string]literalServer `default:"a=b"`
+		}{},
+		&struct {
+			S []int `default:"json:[1,"`
+		}{},
+	}
+	for _, c := range cases {
+		if err := LoadStruct(c, "default"); err == nil {
+			t.Errorf("%T should fail", c)
+		}
+	}
+}
@@ -4,9 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
-	"strconv"
+	"strings"
 
-	"github.com/samber/lo"
+	"github.com/asppj/goload/internal/conv"
 )
 
 type TagOption struct {
@@ -17,7 +17,7 @@ type TagOption struct {
 }
 
 func (t *TagOption) getDefault() []string {
-	if t == nil || t.TagValue == nil {
+	if t == nil || t.TagValue == nil || isLiteral(*t.TagValue) {
 		return []string{}
 	}
 	vals, err := readAsCSV(*t.TagValue)
@@ -115,12 +115,15 @@ func parseSlice(v reflect.Value, option *TagOption) error {
 
 func parseMap(v reflect.Value, option *TagOption) error {
 	if option.setZero && v.Len() > 0 {
+		// the elements are set by the default of the map, only the defaults
+		// of their fields are left
+		eleOption := &TagOption{TagName: option.TagName, fullTag: option.fullTag}
 		iter := v.MapRange()
 		for iter.Next() {
 			indexValue := iter.Value()
 			tmp := reflect.New(indexValue.Type())
 			tmp.Elem().Set(indexValue)
-			if err := parseValue(tmp.Elem(), option); err != nil {
+			if err := parseValue(tmp.Elem(), eleOption); err != nil {
 				return err
 			}
 			v.SetMapIndex(iter.Key(), tmp.Elem())
@@ -144,6 +147,17 @@ func parseSample(v reflect.Value, option *TagOption) error {
 		if strV == "-" {
 			return nil
 		}
+		if v.Type() == typeOfTime {
+			t, err := conv.ParseTime(strV, option.fullTag.Get(LayoutTag))
+			if err != nil {
+				return parseError(strV, v.Type(), err)
+			}
+			v.Set(reflect.ValueOf(t))
+			return nil
+		}
+		if option.fullTag.Get(UnitTag) == UnitBytes {
+			return parseByteSize(v, strV)
+		}
 		if err := parseSimpleValue(v, strV); err != nil {
 			return err
 		}
@@ -153,7 +167,12 @@ func parseSample(v reflect.Value, option *TagOption) error {
 
 // parse reflect.Value set default value
 func parseValue(v reflect.Value, option *TagOption) error {
-	setZeroType(v, option)
+	if v.Kind() != reflect.Pointer && isValueType(v.Type()) {
+		return parseSample(v, option) // time.Time, net.IP... are values, not structs or slices of options
+	}
+	if err := setZeroType(v, option); err != nil {
+		return err
+	}
 	switch v.Type().Kind() {
 	case reflect.Struct:
 		return parseStruct(v, option)
@@ -171,70 +190,87 @@ func parseValue(v reflect.Value, option *TagOption) error {
 	}
 }
 
-func setZeroType(v reflect.Value, option *TagOption) {
+// setZeroType sets the zero value v to its default: a new value for pointers
+// and structs, the elements of the CSV default tag for slices and maps, or
+// the literal form of the default tag, see decodeLiteral.
+func setZeroType(v reflect.Value, option *TagOption) error {
 	if !v.CanSet() {
-		return
+		return nil
 	}
 	switch v.Kind() {
 	case reflect.Pointer:
 		if !v.IsZero() {
-			return
+			return nil
 		}
 		zero := reflect.New(v.Type().Elem())
 		v.Set(zero)
 		option.setZero = true
 	case reflect.Struct:
 		if !v.IsZero() {
-			return
+			return nil
 		}
 		zero := reflect.New(v.Type())
 		v.Set(reflect.Indirect(zero))
+		if _, err := decodeLiteral(v, option); err != nil {
+			return err
+		}
 		option.setZero = true
 	case reflect.Array, reflect.Slice:
 		if !v.IsZero() {
-			return
+			return nil
+		}
+		if ok, err := decodeLiteral(v, option); ok {
+			option.setZero = true
+			return err
 		}
 		vals := option.getDefault()
 		slice := reflect.MakeSlice(v.Type(), len(vals), len(vals))
-		// for i := 0; i < len(vals); i++ {
-		// 	if err := parseSimpleValue(slice.Index(i), vals[i]); err != nil {
-		// 		panic(err)
-		// 	}
-		// }
 		v.Set(slice)
 		option.setZero = true
 	case reflect.Map:
 		if !v.IsZero() {
-			return
+			return nil
+		}
+		if ok, err := decodeLiteral(v, option); ok {
+			option.setZero = true
+			return err
 		}
 		vals := option.getDefault()
 		m := reflect.MakeMapWithSize(v.Type(), len(vals))
 		// key type,value type
 		kt, vt := v.Type().Key(), v.Type().Elem()
 		for i := 0; i < len(vals); i++ {
-			ele := zeroType(vt)
-			if err := parseValue(ele, option); err != nil {
-				panic(err)
+			// key=value, or a key with the default value of the type
+			k, value, hasValue := strings.Cut(vals[i], "=")
+			key := reflect.New(kt).Elem()
+			if err := parseSimpleValue(key, k); err != nil {
+				return err
 			}
-			if lo.Contains([]reflect.Kind{reflect.Int, reflect.Uint, reflect.Int8, reflect.Int16, reflect.Int16, reflect.Int32, reflect.Int64,
-				reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64}, kt.Kind()) {
-				if kValue, err := strconv.Atoi(vals[i]); err == nil {
-					m.SetMapIndex(reflect.ValueOf(kValue).Convert(kt), ele)
+			ele := reflect.New(vt).Elem()
+			ele.Set(zeroType(vt))
+			eleOption := &TagOption{TagName: option.TagName, fullTag: option.fullTag}
+			if hasValue {
+				if t := reflect.Indirect(ele).Type(); t.Kind() == reflect.Struct && !isValueType(t) {
+					return fmt.Errorf("value of key %q: structs are set by the json: or yaml: literal form", k)
 				}
-			} else { // string
-				m.SetMapIndex(reflect.ValueOf(vals[i]).Convert(kt), ele)
+				eleOption.TagValue = &value
+			}
+			if err := parseValue(ele, eleOption); err != nil {
+				return fmt.Errorf("value of key %q: %w", k, err)
 			}
+			m.SetMapIndex(key, ele)
 		}
 		v.Set(m)
 		option.setZero = true
 	default:
 		if !v.IsZero() {
-			return
+			return nil
 		}
 		zero := reflect.Zero(v.Type())
 		v.Set(zero)
 		option.setZero = true
 	}
+	return nil
 }
 
 func zeroPointValue(tv reflect.Value) reflect.Value {
@@ -0,0 +1,33 @@
+package goload
+
+import (
+	"net"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"testing"
+)
+
+type netConf struct {
+	Listen   netip.AddrPort `default:"0.0.0.0:8080"`
+	WhiteIP  []net.IP       `default:"127.0.0.1,10.0.0.1"`
+	Trusted  *net.IPNet     `default:"192.168.0.0/16"`
+	Upstream *url.URL       `default:"https://api.local/v1"`
+	Pattern  *regexp.Regexp `default:"^/api/"`
+}
+
+func TestLoadStructNetTypes(t *testing.T) {
+	c := netConf{}
+	if err := LoadStruct(&c, "default"); err != nil {
+		t.Fatal(err)
+	}
+	if c.Listen.Port() != 8080 {
+		t.Errorf("listen: %v", c.Listen)
+	}
+	if len(c.WhiteIP) != 2 || c.WhiteIP[1].String() != "10.0.0.1" {
+		t.Errorf("white ip: %v", c.WhiteIP)
+	}
+	if c.Trusted.String() != "192.168.0.0/16" || c.Upstream.Host != "api.local" || !c.Pattern.MatchString("/api/x") {
+		t.Errorf("%v %v %v", c.Trusted, c.Upstream, c.Pattern)
+	}
+}
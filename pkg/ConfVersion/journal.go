@@ -0,0 +1,126 @@
+package ConfVersion
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/asppj/goload/pkg/parse"
+)
+
+const journalIndex = "index.json"
+
+// journal persists the versions of a store in a directory: one file per
+// version named after it, e.g. 3.yaml, plus an index with the active version.
+type journal struct {
+	dir     string
+	ext     string
+	encoder parse.MarshalFunc
+	decoder parse.UnmarshalFunc
+}
+
+// journalIndexFile is the content of the index.
+type journalIndexFile struct {
+	Version  int   `json:"version"`  // latest version
+	Active   int   `json:"active"`   // active version
+	Versions []int `json:"versions"` // retained versions
+	Pinned   []int `json:"pinned"`   // pinned versions
+}
+
+func newJournal(dir, format string) (*journal, error) {
+	j := &journal{dir: dir, ext: format}
+	switch format {
+	case parse.JSON:
+		j.encoder, j.decoder = parse.JSONEncoder, parse.JSONDecoder
+	case parse.YAML:
+		j.encoder, j.decoder = parse.YAMLEncoder, parse.YAMLDecoder
+	case parse.TOML:
+		j.encoder, j.decoder = parse.TOMLEncoder, parse.TOMLDecoder
+	default:
+		return nil, fmt.Errorf("journal format %q not supported", format)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+func (j *journal) versionPath(version int) string {
+	return filepath.Join(j.dir, strconv.Itoa(version)+"."+j.ext)
+}
+
+func (j *journal) writeVersion(version int, conf any) error {
+	content, err := j.encoder(conf)
+	if err != nil {
+		return fmt.Errorf("encode version %d: %w", version, err)
+	}
+	return writeFileAtomic(j.versionPath(version), content)
+}
+
+func (j *journal) readVersion(version int, conf any) error {
+	content, err := os.ReadFile(j.versionPath(version))
+	if err != nil {
+		return err
+	}
+	if err := j.decoder(content, conf); err != nil {
+		return fmt.Errorf("decode version %d: %w", version, err)
+	}
+	return nil
+}
+
+func (j *journal) removeVersion(version int) error {
+	err := os.Remove(j.versionPath(version))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (j *journal) writeIndex(index journalIndexFile) error {
+	content, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(j.dir, journalIndex), content)
+}
+
+// readIndex returns false if the journal is empty.
+func (j *journal) readIndex() (journalIndexFile, bool, error) {
+	var index journalIndexFile
+	content, err := os.ReadFile(filepath.Join(j.dir, journalIndex))
+	if errors.Is(err, os.ErrNotExist) {
+		return index, false, nil
+	}
+	if err != nil {
+		return index, false, err
+	}
+	if err := json.Unmarshal(content, &index); err != nil {
+		return index, false, fmt.Errorf("decode %s: %w", journalIndex, err)
+	}
+	return index, true, nil
+}
+
+// writeFileAtomic writes a temporary file next to path then renames it, so
+// readers see either the old or the new content.
+func writeFileAtomic(path string, content []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
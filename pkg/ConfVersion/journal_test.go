@@ -0,0 +1,222 @@
+package ConfVersion
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/asppj/goload/pkg/parse"
+)
+
+func TestOpenStore(t *testing.T) {
+	for _, format := range []string{parse.JSON, parse.YAML, parse.TOML} {
+		t.Run(format, func(t *testing.T) {
+			dir := t.TempDir()
+			s, err := OpenStore[versionConf](dir, 2, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for port := 1; port <= 4; port++ {
+				if err := s.Load(versionConf{Port: port}); err != nil {
+					t.Fatal(err)
+				}
+				if port == 1 {
+					if err := s.Pin(1); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			if err := s.Rollback(3); err != nil {
+				t.Fatal(err)
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var files []string
+			for _, e := range entries {
+				files = append(files, e.Name())
+			}
+			sort.Strings(files)
+			want := []string{"1." + format, "3." + format, "4." + format, "index.json"}
+			if len(files) != len(want) {
+				t.Fatalf("files %v, want %v", files, want)
+			}
+			for i := range want {
+				if files[i] != want[i] {
+					t.Fatalf("files %v, want %v", files, want)
+				}
+			}
+
+			restored, err := OpenStore[versionConf](dir, 2, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if restored.Version() != 3 || restored.Get().Port != 3 {
+				t.Fatalf("restored version %d conf %+v", restored.Version(), restored.Get())
+			}
+			if got := historyVersions(restored); !equalInts(got, []int{1, 3, 4}) {
+				t.Fatalf("restored history %v", got)
+			}
+			if err := restored.Load(versionConf{Port: 5}); err != nil {
+				t.Fatal(err)
+			}
+			if restored.Version() != 5 {
+				t.Fatalf("version %d after restore and load", restored.Version())
+			}
+		})
+	}
+}
+
+func TestOpenStoreRetention(t *testing.T) {
+	dir := t.TempDir()
+	s, err := OpenStore[versionConf](dir, 0, parse.JSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for port := 1; port <= 4; port++ {
+		if err := s.Load(versionConf{Port: port}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a smaller retention at startup keeps only the last versions
+	restored, err := OpenStore[versionConf](dir, 1, parse.JSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := historyVersions(restored); !equalInts(got, []int{4}) {
+		t.Fatalf("restored history %v", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "1.json")); !os.IsNotExist(err) {
+		t.Fatalf("pruned version file kept: %v", err)
+	}
+	if _, err := OpenStore[versionConf](dir, 1, "ini"); err == nil {
+		t.Fatal("unsupported format accepted")
+	}
+}
+
+func TestStoreIndexError(t *testing.T) {
+	dir := t.TempDir()
+	s, err := OpenStore[versionConf](dir, 2, parse.JSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for port := 1; port <= 2; port++ {
+		if err := s.Load(versionConf{Port: port}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the index can not be replaced by a directory
+	index := filepath.Join(dir, "index.json")
+	if err := os.Remove(index); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(index, "dir"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Load(versionConf{Port: 3}); err == nil {
+		t.Fatal("load should fail")
+	}
+	if err := s.Rollback(1); err == nil {
+		t.Fatal("rollback should fail")
+	}
+	if err := s.Pin(1); err == nil {
+		t.Fatal("pin should fail")
+	}
+	// the store is unchanged
+	if s.Version() != 2 || s.Get().Port != 2 {
+		t.Fatalf("version %d conf %+v", s.Version(), s.Get())
+	}
+	for _, v := range s.History() {
+		if v.Pinned {
+			t.Fatalf("history %+v", s.History())
+		}
+	}
+	if got := historyVersions(s); !equalInts(got, []int{1, 2}) {
+		t.Fatalf("history %v", got)
+	}
+
+	if err := os.RemoveAll(index); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Load(versionConf{Port: 4}); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := OpenStore[versionConf](dir, 2, parse.JSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.Version() != 3 || restored.Get().Port != 4 {
+		t.Fatalf("restored version %d conf %+v", restored.Version(), restored.Get())
+	}
+}
+
+func TestStorePruneError(t *testing.T) {
+	dir := t.TempDir()
+	s, err := OpenStore[versionConf](dir, 1, parse.JSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Load(versionConf{Port: 1}); err != nil {
+		t.Fatal(err)
+	}
+	// the file of version 1 can not be removed while it is a non-empty directory
+	version1 := filepath.Join(dir, "1.json")
+	if err := os.Remove(version1); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(version1, "dir"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Load(versionConf{Port: 2}); err != nil {
+		t.Fatalf("the load took effect: %v", err)
+	}
+	if s.Version() != 2 || s.PruneError() == nil {
+		t.Fatalf("version %d prune error %v", s.Version(), s.PruneError())
+	}
+	if got := historyVersions(s); !equalInts(got, []int{2}) {
+		t.Fatalf("history %v", got)
+	}
+	// the removal is retried by the next change
+	if err := os.Remove(filepath.Join(version1, "dir")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Load(versionConf{Port: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(version1); !os.IsNotExist(err) || s.PruneError() != nil {
+		t.Fatalf("version 1 not removed: %v, %v", err, s.PruneError())
+	}
+}
+
+func TestOpenStoreMissingVersion(t *testing.T) {
+	dir := t.TempDir()
+	s, err := OpenStore[versionConf](dir, 0, parse.JSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for port := 1; port <= 3; port++ {
+		if err := s.Load(versionConf{Port: port}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Remove(filepath.Join(dir, "1.json")); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := OpenStore[versionConf](dir, 0, parse.JSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := historyVersions(restored); !equalInts(got, []int{2, 3}) || restored.Get().Port != 3 {
+		t.Fatalf("history %v conf %+v", got, restored.Get())
+	}
+	// the active version can not be skipped
+	if err := os.Remove(filepath.Join(dir, "3.json")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenStore[versionConf](dir, 0, parse.JSON); err == nil {
+		t.Fatal("open should fail without the active version")
+	}
+}
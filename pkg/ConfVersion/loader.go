@@ -1,20 +1,237 @@
 package ConfVersion
 
 import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
 	"sync"
+	"time"
 
-	"github.com/spf13/viper"
+	"github.com/asppj/goload/pkg/parse"
 )
 
-type loader struct {
-	vp     map[string]*viper.Viper
-	locker sync.RWMutex
+// BaseUser is the key of the shared base config, returned by Get for the
+// users without a config of their own. The configs of the other users are
+// sparse overlays loaded on top of a copy of the base config.
+const BaseUser = ""
+
+// userConf is the config of a user and the URL it was loaded from.
+type userConf[T any] struct {
+	url  string
+	conf *T
+}
+
+// loader loads a config per user (tenant) from an URL:
+//
+//	file:///etc/app/conf.yaml, file://conf/app.yaml (relative)
+//	http://host/conf.json, https://host/conf.json
+//	env://APP (env with the prefix APP)
+//
+// The base config, and a user config loaded without base config, get the
+// default tags; each config is checked by the valid tags and, if it
+// implements ConfValid, by Valid.
+//
+// A user config only has the keys the user changes, e.g. redis.db: 7, and is
+// loaded on top of the base config: structs are merged field by field, maps
+// key by key and slices are replaced, or appended with
+// parse.SetSliceMerge(parse.MergeAppend).
+type loader[T any] struct {
+	vp         map[string]*userConf[T]
+	generation int // incremented when the base config changes
+	opts       []parse.SetOpt
+	client     *http.Client
+	locker     sync.RWMutex
+}
+
+// maxConfigSize is the size limit of the configs loaded over http.
+const maxConfigSize = 10 << 20
+
+// NewLoader returns a loader parsing the configs with opts. Files and http
+// bodies are decoded by the extension of their path, or by parse.SetIdent,
+// json by default.
+func NewLoader[T any](opts ...parse.SetOpt) *loader[T] {
+	return &loader[T]{
+		vp:     make(map[string]*userConf[T]),
+		opts:   append([]parse.SetOpt{parse.SetIdent(parse.JSON)}, opts...),
+		client: &http.Client{Timeout: 10 * time.Second},
+		locker: sync.RWMutex{},
+	}
+}
+
+// Load loads the config of userValue from configURL and replaces the
+// previous one, the previous config is kept if loading fails. Loading the
+// base config reloads the user configs on top of it.
+func (l *loader[T]) Load(userValue string, configURL string) error {
+	if err := l.load(userValue, configURL); err != nil {
+		return err
+	}
+	if userValue == BaseUser {
+		return l.reloadUsers()
+	}
+	return nil
+}
+
+func (l *loader[T]) load(userValue string, configURL string) error {
+	for {
+		l.locker.RLock()
+		generation := l.generation
+		base := l.vp[BaseUser]
+		l.locker.RUnlock()
+
+		conf := new(T)
+		var opts []parse.SetOpt
+		if userValue != BaseUser && base != nil {
+			// the base config already has the default tags, a zero value
+			// it sets is kept
+			conf = deepCopy(base.conf)
+			opts = append(opts, parse.SetOverlay(true))
+		}
+		if err := l.fetch(configURL, conf, opts...); err != nil {
+			return fmt.Errorf("load config of user %q: %w", userValue, err)
+		}
+
+		l.locker.Lock()
+		if userValue != BaseUser && generation != l.generation {
+			// the base config changed while loading
+			l.locker.Unlock()
+			continue
+		}
+		l.vp[userValue] = &userConf[T]{url: configURL, conf: conf}
+		if userValue == BaseUser {
+			l.generation++
+		}
+		l.locker.Unlock()
+		return nil
+	}
+}
+
+// reloadUsers loads the user configs on top of a new base config, the users
+// failing keep their previous config.
+func (l *loader[T]) reloadUsers() error {
+	l.locker.RLock()
+	users := make(map[string]string, len(l.vp))
+	for user, c := range l.vp {
+		if user != BaseUser {
+			users[user] = c.url
+		}
+	}
+	l.locker.RUnlock()
+
+	var failed []string
+	var firstErr error
+	for user, configURL := range users {
+		if err := l.load(user, configURL); err != nil {
+			failed = append(failed, user)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	if firstErr != nil {
+		sort.Strings(failed)
+		return fmt.Errorf("reload configs of users %q on the base config: %w", failed, firstErr)
+	}
+	return nil
+}
+
+// Get returns the config of userValue, or the base config if the user has
+// none. It returns false if neither is loaded. The config must not be
+// modified.
+func (l *loader[T]) Get(userValue string) (*T, bool) {
+	l.locker.RLock()
+	defer l.locker.RUnlock()
+	if c, ok := l.vp[userValue]; ok {
+		return c.conf, true
+	}
+	if c, ok := l.vp[BaseUser]; ok {
+		return c.conf, true
+	}
+	return nil, false
+}
+
+// Reload loads the config of userValue again from its URL.
+func (l *loader[T]) Reload(userValue string) error {
+	l.locker.RLock()
+	c, ok := l.vp[userValue]
+	l.locker.RUnlock()
+	if !ok {
+		return fmt.Errorf("reload config of user %q: not loaded", userValue)
+	}
+	return l.Load(userValue, c.url)
+}
+
+// Remove removes the config of userValue, Get falls back to the base config.
+// Removing the base config keeps the user configs loaded on top of it.
+func (l *loader[T]) Remove(userValue string) {
+	l.locker.Lock()
+	defer l.locker.Unlock()
+	delete(l.vp, userValue)
+	if userValue == BaseUser {
+		l.generation++
+	}
+}
+
+// fetch loads configURL into conf, opts are added to the options of the
+// loader.
+func (l *loader[T]) fetch(configURL string, conf *T, opts ...parse.SetOpt) error {
+	u, err := url.Parse(configURL)
+	if err != nil {
+		return err
+	}
+	switch u.Scheme {
+	case "file":
+		// file://conf/app.yaml is relative: conf is parsed as the host
+		path := u.Host + u.Path
+		err = l.parser(append(opts, parse.SetSources(parse.FileSource(path)))...).Resolve(conf)
+	case "env":
+		err = l.parser(append(opts, parse.SetEnvPrefix(u.Host), parse.SetSources(parse.EnvSource()))...).Resolve(conf)
+	case "http", "https":
+		err = l.fetchHTTP(u, conf, opts)
+	default:
+		return fmt.Errorf("config url %q: scheme %q not supported", configURL, u.Scheme)
+	}
+	if err != nil {
+		return err
+	}
+	if v, ok := any(conf).(ConfValid); ok {
+		return checkValid(v)
+	}
+	if v, ok := any(*conf).(ConfValid); ok {
+		return checkValid(v)
+	}
+	return nil
 }
 
-func NewLoader() *loader {
-	return &loader{vp: make(map[string]*viper.Viper), locker: sync.RWMutex{}}
+// parser returns a parser with the options of the loader and opts, the
+// options of the loader are copied as fetch is called concurrently.
+func (l *loader[T]) parser(opts ...parse.SetOpt) parse.Parser {
+	all := make([]parse.SetOpt, 0, len(l.opts)+len(opts))
+	return parse.NewParser(append(append(all, l.opts...), opts...)...)
 }
 
-func (l *loader) Load(userValue string, configURL string) error {
+// fetchHTTP loads the body of u into conf, its format is detected by the
+// extension of the path, without the query.
+func (l *loader[T]) fetchHTTP(u *url.URL, conf *T, opts []parse.SetOpt) error {
+	configURL := u.String()
+	resp, err := l.client.Get(configURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("get %s: %s", configURL, resp.Status)
+	}
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxConfigSize+1))
+	if err != nil {
+		return err
+	}
+	if len(content) > maxConfigSize {
+		return fmt.Errorf("get %s: config larger than %d bytes", configURL, maxConfigSize)
+	}
+	if err := l.parser(append(opts, parse.SetSources(parse.ContentSource(u.Path, content)))...).Resolve(conf); err != nil {
+		return fmt.Errorf("get %s: %w", configURL, err)
+	}
 	return nil
 }
@@ -1,6 +1,12 @@
 package ConfVersion
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/asppj/goload/conf"
@@ -16,3 +22,198 @@ func TestLoader(t *testing.T) {
 	}
 	t.Log("success")
 }
+
+type tenantConf struct {
+	Name string `json:"name" default:"app"`
+	Port int    `json:"port" default:"8080" valid:"max=65535"`
+}
+
+func writeTenantFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "conf.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoaderURLs(t *testing.T) {
+	l := NewLoader[tenantConf]()
+	if _, ok := l.Get("alice"); ok {
+		t.Fatal("config of an unknown user without base")
+	}
+
+	base := writeTenantFile(t, `{"name": "base"}`)
+	if err := l.Load(BaseUser, "file://"+base); err != nil {
+		t.Fatal(err)
+	}
+	if c, ok := l.Get("alice"); !ok || c.Name != "base" || c.Port != 8080 {
+		t.Fatalf("base fallback: %+v", c)
+	}
+
+	t.Setenv("ALICE_PORT", "9001")
+	if err := l.Load("alice", "env://ALICE"); err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := l.Get("alice"); c.Name != "base" || c.Port != 9001 {
+		t.Fatalf("env config: %+v", c)
+	}
+
+	body := `{"name": "bob", "port": 9002}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/big.json" {
+			_, _ = w.Write([]byte(`{"name": "` + strings.Repeat("a", maxConfigSize) + `"}`))
+			return
+		}
+		if r.URL.Path != "/bob.json" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+	if err := l.Load("bob", srv.URL+"/bob.json"); err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := l.Get("bob"); c.Name != "bob" || c.Port != 9002 {
+		t.Fatalf("http config: %+v", c)
+	}
+
+	body = `{"name": "bob", "port": 9003}`
+	if err := l.Reload("bob"); err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := l.Get("bob"); c.Port != 9003 {
+		t.Fatalf("reloaded config: %+v", c)
+	}
+	// an invalid config keeps the previous one
+	body = `{"port": 70000}`
+	if err := l.Reload("bob"); err == nil {
+		t.Fatal("invalid config loaded")
+	}
+	if c, _ := l.Get("bob"); c.Port != 9003 {
+		t.Fatalf("config after failed reload: %+v", c)
+	}
+
+	l.Remove("bob")
+	if c, _ := l.Get("bob"); c.Name != "base" {
+		t.Fatalf("removed user: %+v", c)
+	}
+	if err := l.Reload("bob"); err == nil {
+		t.Fatal("removed user reloaded")
+	}
+	if err := l.Load("carol", srv.URL+"/missing.json"); err == nil {
+		t.Fatal("missing http config loaded")
+	}
+	if err := l.Load("carol", srv.URL+"/big.json"); err == nil {
+		t.Fatal("config larger than the limit loaded")
+	}
+	if err := l.Load("carol", "ftp://host/conf.json"); err == nil {
+		t.Fatal("unsupported scheme loaded")
+	}
+}
+
+type overlayConf struct {
+	Name  string            `yaml:"name" default:"app"`
+	Hosts []string          `yaml:"hosts"`
+	Tags  map[string]string `yaml:"tags"`
+	Redis *overlayRedis     `yaml:"redis"`
+}
+
+type overlayRedis struct {
+	Host string `yaml:"host" default:"127.0.0.1"`
+	DB   int    `yaml:"db"`
+}
+
+func TestLoaderOverlay(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return "file://" + path
+	}
+	base := write("base.yaml", "name: base\nhosts: [a]\ntags:\n  env: prod\nredis:\n  host: redis\n  db: 1\n")
+	tenant := write("tenant.yaml", "hosts: [b]\ntags:\n  team: x\nredis:\n  db: 7\n")
+
+	for _, tt := range []struct {
+		mode  parse.MergeMode
+		hosts []string
+	}{
+		{parse.MergeReplace, []string{"b"}},
+		{parse.MergeAppend, []string{"a", "b"}},
+	} {
+		t.Run(tt.mode.String(), func(t *testing.T) {
+			l := NewLoader[overlayConf](parse.SetIdent(parse.YAML), parse.SetSliceMerge(tt.mode))
+			if err := l.Load(BaseUser, base); err != nil {
+				t.Fatal(err)
+			}
+			if err := l.Load("tenant", tenant); err != nil {
+				t.Fatal(err)
+			}
+			c, _ := l.Get("tenant")
+			want := overlayConf{
+				Name:  "base",
+				Hosts: tt.hosts,
+				Tags:  map[string]string{"env": "prod", "team": "x"},
+				Redis: &overlayRedis{Host: "redis", DB: 7},
+			}
+			if !reflect.DeepEqual(*c, want) {
+				t.Fatalf("tenant config %+v, want %+v", *c, want)
+			}
+			// the base config is not modified by the overlay
+			b, _ := l.Get(BaseUser)
+			if b.Redis.DB != 1 || len(b.Hosts) != 1 || len(b.Tags) != 1 {
+				t.Fatalf("base config modified: %+v", *b)
+			}
+		})
+	}
+
+	// loading the base config again reloads the tenants on top of it
+	l := NewLoader[overlayConf](parse.SetIdent(parse.YAML))
+	if err := l.Load(BaseUser, base); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Load("tenant", tenant); err != nil {
+		t.Fatal(err)
+	}
+	base = write("base.yaml", "name: base2\nredis:\n  host: redis2\n")
+	if err := l.Reload(BaseUser); err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := l.Get("tenant"); c.Name != "base2" || c.Redis.Host != "redis2" || c.Redis.DB != 7 {
+		t.Fatalf("tenant config after base reload %+v", *c)
+	}
+
+	// an overlay over http is layered as a file: its empty host is kept
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hosts: [c]\nredis:\n  host: \"\"\n"))
+	}))
+	defer srv.Close()
+	if err := l.Load("web", srv.URL+"/web.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := l.Get("web"); c.Name != "base2" || c.Redis.Host != "" || len(c.Hosts) != 1 || c.Hosts[0] != "c" {
+		t.Fatalf("http overlay %+v, redis %+v", *c, *c.Redis)
+	}
+
+	// the zero values set by the base config are not replaced by the default
+	// tags in the tenants
+	base = write("zero.yaml", "name: \"\"\nredis:\n  host: \"\"\n")
+	if err := l.Load(BaseUser, base); err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := l.Get("tenant"); c.Name != "" || c.Redis.Host != "" || c.Redis.DB != 7 {
+		t.Fatalf("tenant config on a zero base %+v, redis %+v", *c, *c.Redis)
+	}
+
+	// the format is detected by the path of the url, without the query
+	l = NewLoader[overlayConf]()
+	if err := l.Load(BaseUser, srv.URL+"/conf.yaml?rev=3"); err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := l.Get(BaseUser); len(c.Hosts) != 1 || c.Hosts[0] != "c" || c.Name != "app" {
+		t.Fatalf("yaml over http %+v", *c)
+	}
+}
@@ -1,48 +1,308 @@
 package ConfVersion
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
 )
 
 type ConfValid interface {
 	Valid() (bool, error)
 }
 
-type confLoader[T ConfValid] struct {
-	version      int       // 当前版本
-	mod          int       // 保留最近几个版本
-	versionCache map[int]T // 版本
+// checkValid returns an error if v is not valid.
+func checkValid(v ConfValid) error {
+	valid, err := v.Valid()
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return errors.New("valid err: config is invalid")
+	}
+	return nil
+}
+
+// ConfLoader 版本化配置
+type ConfLoader[T ConfValid] interface {
+	Load(newConf T) error       // validate newConf and activate it as a new version
+	Get() T                     // active config
+	Version() int               // active version, 0 before the first Load
+	History() []Version[T]      // retained versions, oldest first
+	Rollback(version int) error // activate a retained version
+	Pin(version int) error      // retain the version whatever the retention
+	Unpin(version int) error    // let the version be pruned again
 }
 
-type ConfLoader interface {
+// Version is a retained version of a config.
+type Version[T ConfValid] struct {
+	Version int
+	Conf    T
+	Active  bool
+	Pinned  bool
 }
 
-func NewConfLoader[T ConfValid](mod int) ConfLoader {
-	return &confLoader[T]{
-		version:      0,
+// ErrVersionNotFound is returned for versions which were never loaded or
+// have been pruned.
+var ErrVersionNotFound = errors.New("version not found")
+
+// snapshot is the active version, replaced atomically.
+type snapshot[T ConfValid] struct {
+	version int
+	conf    T
+}
+
+// Store keeps the versions of a config: readers get the active version
+// without locking, writers are serialized.
+type Store[T ConfValid] struct {
+	active       atomic.Value     // *snapshot[T]
+	mu           sync.Mutex       // serializes writers
+	version      int              // latest version
+	mod          int              // 保留最近几个版本, <= 0 keeps all
+	versionCache map[int]T        // 版本
+	pinned       map[int]struct{} // versions never pruned
+	journal      *journal         // nil if the versions are not persisted
+	unremoved    map[int]struct{} // pruned versions whose file is not removed yet
+	pruneErr     error            // error of the last removal of the pruned files
+}
+
+// NewStore returns a store keeping the latest mod versions, pinned versions
+// and the active version are always kept; mod <= 0 keeps all the versions.
+func NewStore[T ConfValid](mod int) *Store[T] {
+	s := &Store[T]{
 		mod:          mod,
 		versionCache: make(map[int]T),
+		pinned:       make(map[int]struct{}),
+		unremoved:    make(map[int]struct{}),
 	}
+	s.active.Store(&snapshot[T]{})
+	return s
 }
 
-func (c *confLoader[T]) Load(newConf T) error {
-	valid, err := newConf.Valid()
+// OpenStore returns a store persisting its versions in dir, one file per
+// version encoded in format (json, yaml or toml) plus an index written
+// atomically. The latest mod versions, the pinned versions and the active
+// version left by a previous run are restored; the files of the other
+// versions which are missing are skipped.
+func OpenStore[T ConfValid](dir string, mod int, format string) (*Store[T], error) {
+	j, err := newJournal(dir, format)
 	if err != nil {
+		return nil, err
+	}
+	s := NewStore[T](mod)
+	s.journal = j
+	index, ok, err := j.readIndex()
+	if err != nil || !ok {
+		return s, err
+	}
+	s.version = index.Version
+	for _, version := range index.Pinned {
+		s.pinned[version] = struct{}{}
+	}
+	for _, version := range index.Versions {
+		var conf T
+		err := j.readVersion(version, &conf)
+		if errors.Is(err, os.ErrNotExist) && version != index.Active {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		s.versionCache[version] = conf
+	}
+	if conf, ok := s.versionCache[index.Active]; ok {
+		s.active.Store(&snapshot[T]{version: index.Active, conf: conf})
+	}
+	return s, s.commit(s.version, s.versions(), s.snapshot(), s.pinned)
+}
+
+func NewConfLoader[T ConfValid](mod int) ConfLoader[T] {
+	return NewStore[T](mod)
+}
+
+// Load validates newConf and activates it as a new version, the version is
+// written to the journal before being activated, see commit.
+func (c *Store[T]) Load(newConf T) error {
+	if err := checkValid(newConf); err != nil {
 		return err
 	}
-	if !valid {
-		return fmt.Errorf("valid err:%v", err)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	version := c.version + 1
+	if c.journal != nil {
+		if err := c.journal.writeVersion(version, newConf); err != nil {
+			return err
+		}
 	}
-	if len(c.versionCache) != 0 {
-		c.version++
+	return c.commit(version, append(c.versions(), version), &snapshot[T]{version: version, conf: newConf}, c.pinned)
+}
+
+// PruneError returns the error of the last removal of the files of the
+// pruned versions, they are removed again by the next change of the store.
+func (c *Store[T]) PruneError() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.pruneErr
+}
+
+// Get returns the active config, the zero value before the first Load.
+func (c *Store[T]) Get() T {
+	return c.snapshot().conf
+}
+
+// Version returns the active version, 0 before the first Load.
+func (c *Store[T]) Version() int {
+	return c.snapshot().version
+}
+
+func (c *Store[T]) snapshot() *snapshot[T] {
+	return c.active.Load().(*snapshot[T])
+}
+
+// History returns the retained versions, oldest first.
+func (c *Store[T]) History() []Version[T] {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	active := c.Version()
+	history := make([]Version[T], 0, len(c.versionCache))
+	for _, version := range c.versions() {
+		_, pinned := c.pinned[version]
+		history = append(history, Version[T]{
+			Version: version,
+			Conf:    c.versionCache[version],
+			Active:  version == active,
+			Pinned:  pinned,
+		})
 	}
-	c.versionCache[c.version] = newConf
-	if c.mod > 1 && len(c.versionCache) > c.mod { // 0,1,2; 2
-		delete(c.versionCache, c.version-c.mod) // 删除老版本
+	return history
+}
+
+// Rollback activates a retained version, the next Load still creates a
+// version after the latest one.
+func (c *Store[T]) Rollback(version int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	conf, ok := c.versionCache[version]
+	if !ok {
+		return fmt.Errorf("rollback to %d: %w", version, ErrVersionNotFound)
 	}
-	return nil
+	return c.commit(c.version, c.versions(), &snapshot[T]{version: version, conf: conf}, c.pinned)
+}
+
+// Pin retains the version whatever the retention, e.g. a known-good config.
+func (c *Store[T]) Pin(version int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.versionCache[version]; !ok {
+		return fmt.Errorf("pin %d: %w", version, ErrVersionNotFound)
+	}
+	pinned := copyPinned(c.pinned)
+	pinned[version] = struct{}{}
+	return c.commit(c.version, c.versions(), c.snapshot(), pinned)
+}
+
+// Unpin lets the version be pruned again.
+func (c *Store[T]) Unpin(version int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.pinned[version]; !ok {
+		return fmt.Errorf("unpin %d: %w", version, ErrVersionNotFound)
+	}
+	pinned := copyPinned(c.pinned)
+	delete(pinned, version)
+	return c.commit(c.version, c.versions(), c.snapshot(), pinned)
+}
+
+func copyPinned(pinned map[int]struct{}) map[int]struct{} {
+	copied := make(map[int]struct{}, len(pinned))
+	for version := range pinned {
+		copied[version] = struct{}{}
+	}
+	return copied
 }
 
-func (c *confLoader[T]) Get() T {
-	return c.versionCache[c.version]
+// versions returns the retained versions in ascending order.
+func (c *Store[T]) versions() []int {
+	versions := make([]int, 0, len(c.versionCache))
+	for version := range c.versionCache {
+		versions = append(versions, version)
+	}
+	sort.Ints(versions)
+	return versions
+}
+
+// prune returns the versions older than the latest mod ones, except the
+// pinned and the active versions; versions are in ascending order.
+func (c *Store[T]) prune(versions []int, active int, pinned map[int]struct{}) (pruned []int) {
+	if c.mod <= 0 || len(versions) <= c.mod {
+		return nil
+	}
+	for _, version := range versions[:len(versions)-c.mod] {
+		if _, ok := pinned[version]; ok || version == active {
+			continue
+		}
+		pruned = append(pruned, version)
+	}
+	return pruned
+}
+
+// commit moves the store to the latest version, the versions, the active
+// snapshot and the pinned versions, the versions pruned by the retention
+// excluded. The index of the journal is written first and the store is only
+// changed once it is written, so on error the store is unchanged and Get
+// still matches the journal. The files of the pruned versions are removed
+// last, so the index never refers to a missing file; removing them is best
+// effort, see PruneError.
+func (c *Store[T]) commit(latest int, versions []int, active *snapshot[T], pinned map[int]struct{}) error {
+	pruned := c.prune(versions, active.version, pinned)
+	if c.journal != nil {
+		index := journalIndexFile{
+			Version:  latest,
+			Active:   active.version,
+			Versions: make([]int, 0, len(versions)),
+			Pinned:   make([]int, 0, len(pinned)),
+		}
+		isPruned := make(map[int]bool, len(pruned))
+		for _, version := range pruned {
+			isPruned[version] = true
+		}
+		for _, version := range versions {
+			if !isPruned[version] {
+				index.Versions = append(index.Versions, version)
+			}
+		}
+		for version := range pinned {
+			index.Pinned = append(index.Pinned, version)
+		}
+		sort.Ints(index.Pinned)
+		if err := c.journal.writeIndex(index); err != nil {
+			return err
+		}
+	}
+	c.version = latest
+	c.pinned = pinned
+	if active.version > 0 {
+		c.versionCache[active.version] = active.conf
+	}
+	for _, version := range pruned {
+		delete(c.versionCache, version) // 删除老版本
+	}
+	c.active.Store(active)
+	if c.journal == nil {
+		return nil
+	}
+	for _, version := range pruned {
+		c.unremoved[version] = struct{}{}
+	}
+	c.pruneErr = nil
+	for version := range c.unremoved {
+		if err := c.journal.removeVersion(version); err != nil {
+			c.pruneErr = fmt.Errorf("remove version %d: %w", version, err)
+			continue
+		}
+		delete(c.unremoved, version)
+	}
+	return nil
 }
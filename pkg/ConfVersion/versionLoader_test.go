@@ -0,0 +1,119 @@
+package ConfVersion
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+type versionConf struct {
+	Port int
+}
+
+func (c versionConf) Valid() (bool, error) {
+	return c.Port > 0, nil
+}
+
+func historyVersions(s *Store[versionConf]) []int {
+	var versions []int
+	for _, v := range s.History() {
+		versions = append(versions, v.Version)
+	}
+	return versions
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestStore(t *testing.T) {
+	s := NewStore[versionConf](2)
+	if s.Version() != 0 || s.Get().Port != 0 {
+		t.Fatalf("empty store: version %d conf %+v", s.Version(), s.Get())
+	}
+	if err := s.Load(versionConf{}); err == nil {
+		t.Fatal("invalid config loaded")
+	}
+	for port := 1; port <= 3; port++ {
+		if err := s.Load(versionConf{Port: port}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s.Version() != 3 || s.Get().Port != 3 {
+		t.Fatalf("version %d conf %+v", s.Version(), s.Get())
+	}
+	if got := historyVersions(s); !equalInts(got, []int{2, 3}) {
+		t.Fatalf("history %v", got)
+	}
+	if err := s.Rollback(1); !errors.Is(err, ErrVersionNotFound) {
+		t.Fatalf("rollback to pruned version: %v", err)
+	}
+
+	// the active and pinned versions survive the retention
+	if err := s.Rollback(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Pin(3); err != nil {
+		t.Fatal(err)
+	}
+	for port := 4; port <= 6; port++ {
+		if err := s.Load(versionConf{Port: port}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := historyVersions(s); !equalInts(got, []int{3, 5, 6}) {
+		t.Fatalf("history %v", got)
+	}
+	if err := s.Rollback(3); err != nil {
+		t.Fatal(err)
+	}
+	if s.Version() != 3 || s.Get().Port != 3 {
+		t.Fatalf("version %d conf %+v", s.Version(), s.Get())
+	}
+	if err := s.Load(versionConf{Port: 7}); err != nil {
+		t.Fatal(err)
+	}
+	if s.Version() != 7 {
+		t.Fatalf("version %d after rollback and load", s.Version())
+	}
+	if err := s.Unpin(3); err != nil {
+		t.Fatal(err)
+	}
+	if got := historyVersions(s); !equalInts(got, []int{6, 7}) {
+		t.Fatalf("history %v", got)
+	}
+}
+
+func TestStoreConcurrent(t *testing.T) {
+	s := NewStore[versionConf](3)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func(port int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_ = s.Load(versionConf{Port: port})
+				_ = s.Rollback(s.Version())
+			}
+		}(i + 1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_ = s.Get()
+				_ = s.History()
+			}
+		}()
+	}
+	wg.Wait()
+	if s.Version() == 0 || len(s.History()) > 3 {
+		t.Fatalf("version %d history %v", s.Version(), historyVersions(s))
+	}
+}
@@ -0,0 +1,83 @@
+package parse
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+)
+
+// LoadCmd overrides the fields of the source with command-line flags, one
+// flag per field named by its full ID, e.g. --redis.port=6379. Only the flags
+// explicitly passed are set, so defaults and file values keep their priority.
+func (p *parser) LoadCmd() error {
+	rv, err := p.sourceValue()
+	if err != nil {
+		return err
+	}
+	_, allFields, err := inspectField(rv, nil, p.tagOpt)
+	if err != nil {
+		return err
+	}
+	fs := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	if p.output != nil {
+		fs.SetOutput(p.output)
+	}
+	flags := make(map[string]*fieldFlag)
+	for _, field := range allFields {
+		if field.isParent || !field.canSet {
+			continue
+		}
+		value := &fieldFlag{field: field}
+		fs.Var(value, field.fullID(), field.tagValue.Describe)
+		flags[field.fullID()] = value
+	}
+	args := p.args
+	if args == nil {
+		args = os.Args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if err != nil {
+			return
+		}
+		value := flags[f.Name]
+		if setErr := p.setFieldByString(value.field, value.raw); setErr != nil {
+			err = fmt.Errorf("error parsing flag --%v: %v", f.Name, setErr)
+			return
+		}
+		p.setOrigin(value.field, Origin{Source: SourceCmd, Key: "--" + f.Name})
+	})
+	return err
+}
+
+// fieldFlag is the flag.Value of a field, the raw value is parsed after all
+// the flags are parsed.
+type fieldFlag struct {
+	field *parseField
+	raw   string
+}
+
+// String returns the default tag as the displayed default.
+func (f *fieldFlag) String() string {
+	if f == nil || f.field == nil {
+		return ""
+	}
+	return f.field.tagValue.Default
+}
+
+func (f *fieldFlag) Set(s string) error {
+	f.raw = s
+	return nil
+}
+
+// IsBoolFlag allows --enable without a value for bool fields.
+func (f *fieldFlag) IsBoolFlag() bool {
+	t := f.field.value.Type()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Bool
+}
@@ -0,0 +1,71 @@
+package parse
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+)
+
+type cmdConf struct {
+	Name   string    `yaml:"name" default:"app" desc:"app name"`
+	Debug  bool      `yaml:"debug"`
+	Hosts  []string  `yaml:"hosts" default:"a,b"`
+	Redis  *envRedis `yaml:"redis"`
+	Secret *string   `yaml:"secret"`
+}
+
+func TestLoadCmd(t *testing.T) {
+	c := cmdConf{Name: "fromFile"}
+	p := NewParser(SetIdent(YAML), SetArgs([]string{
+		"--debug", "--hosts=h1,h2", "--redis.port", "6380", "-secret=s",
+	}))
+	if err := p.InspectStruct(&c); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.LoadCmd(); err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "fromFile" {
+		t.Errorf("name not passed should keep its value: %v", c.Name)
+	}
+	if !c.Debug {
+		t.Errorf("debug: %v", c.Debug)
+	}
+	if len(c.Hosts) != 2 || c.Hosts[1] != "h2" {
+		t.Errorf("hosts: %v", c.Hosts)
+	}
+	if c.Redis.Host != "127.0.0.1" || c.Redis.Port != 6380 {
+		t.Errorf("redis: %+v", *c.Redis)
+	}
+	if c.Secret == nil || *c.Secret != "s" {
+		t.Errorf("secret: %v", c.Secret)
+	}
+}
+
+func TestLoadCmdUsage(t *testing.T) {
+	c := cmdConf{}
+	p := NewParser(SetIdent(YAML), SetArgs([]string{"--redis.port=abc"}))
+	if err := p.InspectStruct(&c); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.LoadCmd(); err == nil || !strings.Contains(err.Error(), "redis.port") {
+		t.Errorf("invalid value should fail: %v", err)
+	}
+
+	buf := &bytes.Buffer{}
+	p = NewParser(SetIdent(YAML), SetArgs([]string{"--help"}), SetOutput(buf))
+	if err := p.InspectStruct(&c); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.LoadCmd(); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("--help: %v", err)
+	}
+	usage := buf.String()
+	for _, want := range []string{"-name value", "app name (default app)", "-debug\n", "-hosts value", "(default a,b)", "-redis.port value"} {
+		if !strings.Contains(usage, want) {
+			t.Errorf("usage should contain %q:\n%v", want, usage)
+		}
+	}
+}
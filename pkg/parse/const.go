@@ -11,4 +11,8 @@ const (
 	ValidTag   = "valid"
 	DefaultTag = "default"
 	DescTag    = "desc"
+	OptionTag  = "option"
+	MergeTag   = "merge"
+	LayoutTag  = "layout"
+	UnitTag    = "unit"
 )
@@ -0,0 +1,182 @@
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ChangeKind is the kind of a change.
+type ChangeKind string
+
+const (
+	ChangeAdded   ChangeKind = "added"
+	ChangeRemoved ChangeKind = "removed"
+	ChangeChanged ChangeKind = "changed"
+)
+
+// Change is a value changed between two configs.
+type Change struct {
+	Path string     `json:"path"` // full ID, map keys and slice indices included, e.g. l.0.name
+	Kind ChangeKind `json:"kind"`
+	Old  any        `json:"old,omitempty"` // nil if added
+	New  any        `json:"new,omitempty"` // nil if removed
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case ChangeAdded:
+		return fmt.Sprintf("+ %v: %v", c.Path, formatChangeValue(c.New))
+	case ChangeRemoved:
+		return fmt.Sprintf("- %v: %v", c.Path, formatChangeValue(c.Old))
+	}
+	return fmt.Sprintf("~ %v: %v -> %v", c.Path, formatChangeValue(c.Old), formatChangeValue(c.New))
+}
+
+// Changes is the result of Diff.
+type Changes []Change
+
+// String renders one change per line:
+//
+//	~ redis.host: "a" -> "b"
+//	+ hosts.1: "h2"
+//	- tenants.b: {Host:x}
+func (c Changes) String() string {
+	lines := make([]string, 0, len(c))
+	for _, change := range c {
+		lines = append(lines, change.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// JSON renders the changes as a JSON array.
+func (c Changes) JSON() ([]byte, error) {
+	if c == nil {
+		c = Changes{}
+	}
+	return JSONEncoder(c)
+}
+
+// Diff compares two values of the same config struct, or pointers to it,
+// field by field with the names of the ident tag set by opts. Slices are
+// compared by index and maps by key; a value only in b is added, only in a
+// removed.
+func Diff(a, b any, opts ...SetOpt) (Changes, error) {
+	p := newDefaultParse()
+	for _, opt := range opts {
+		opt(p)
+	}
+	va, vb := indirectValue(reflect.ValueOf(a)), indirectValue(reflect.ValueOf(b))
+	if !va.IsValid() || !vb.IsValid() || va.Kind() != reflect.Struct {
+		return nil, errors.New("config variables must be structs or non nil pointers to structs")
+	}
+	if va.Type() != vb.Type() {
+		return nil, fmt.Errorf("can not diff %v with %v", va.Type(), vb.Type())
+	}
+	return diffStruct(va, vb, p.tagOpt), nil
+}
+
+// diffStruct compares the structs a and b of the same type.
+func diffStruct(a, b reflect.Value, tagOpt *TagOption) Changes {
+	var changes Changes
+	diffValue(nil, a, b, tagOpt, false, &changes)
+	return changes
+}
+
+// diffFields compares the structs a and b of the same type field by field,
+// slices and maps are compared as a whole: a change of hosts.0 is a change of
+// hosts with the old and new slices.
+func diffFields(a, b reflect.Value, tagOpt *TagOption) Changes {
+	var changes Changes
+	diffValue(nil, a, b, tagOpt, true, &changes)
+	return changes
+}
+
+// diffValue appends the changes between a and b to changes, slices and maps
+// are compared as a whole if fields is true.
+func diffValue(parts []string, a, b reflect.Value, tagOpt *TagOption, fields bool, changes *Changes) {
+	a, b = indirectValue(a), indirectValue(b)
+	path := strings.Join(parts, ".")
+	switch {
+	case !a.IsValid() && !b.IsValid():
+		return
+	case !a.IsValid():
+		*changes = append(*changes, Change{Path: path, Kind: ChangeAdded, New: b.Interface()})
+		return
+	case !b.IsValid():
+		*changes = append(*changes, Change{Path: path, Kind: ChangeRemoved, Old: a.Interface()})
+		return
+	}
+	if a.Type() != b.Type() || isDiffLeaf(a.Type()) || fields && a.Kind() != reflect.Struct {
+		if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+			*changes = append(*changes, Change{Path: path, Kind: ChangeChanged, Old: a.Interface(), New: b.Interface()})
+		}
+		return
+	}
+	switch a.Kind() {
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			field := a.Type().Field(i)
+			if isInlined(field, tagOpt.IdentTag) {
+				diffValue(parts, a.Field(i), b.Field(i), tagOpt, fields, changes)
+				continue
+			}
+			ident := identFromField(field, tagOpt.IdentTag)
+			if !field.IsExported() || ident == "-" {
+				continue
+			}
+			diffValue(appendPart(parts, ident), a.Field(i), b.Field(i), tagOpt, fields, changes)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < a.Len() || i < b.Len(); i++ {
+			var ai, bi reflect.Value
+			if i < a.Len() {
+				ai = a.Index(i)
+			}
+			if i < b.Len() {
+				bi = b.Index(i)
+			}
+			diffValue(appendPart(parts, strconv.Itoa(i)), ai, bi, tagOpt, fields, changes)
+		}
+	case reflect.Map:
+		keys := sortedMapKeys(a)
+		for _, key := range sortedMapKeys(b) {
+			if !a.MapIndex(key).IsValid() {
+				keys = append(keys, key)
+			}
+		}
+		for _, key := range keys {
+			diffValue(appendPart(parts, fmt.Sprint(key)), a.MapIndex(key), b.MapIndex(key), tagOpt, fields, changes)
+		}
+	}
+}
+
+// isDiffLeaf returns true if values of t are compared as a whole.
+func isDiffLeaf(t reflect.Type) bool {
+	if t == typeOfByteSlice || isTextUnmarshaler(t) || t.Implements(typeOfTextMarshaler) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Struct, reflect.Slice, reflect.Array, reflect.Map:
+		return false
+	}
+	return true
+}
+
+func appendPart(parts []string, part string) []string {
+	return append(append([]string(nil), parts...), part)
+}
+
+func formatChangeValue(v any) string {
+	if s, ok := v.(string); ok {
+		return strconv.Quote(s)
+	}
+	if v != nil {
+		if text, ok := marshalText(reflect.ValueOf(v)); ok {
+			return text
+		}
+	}
+	return fmt.Sprintf("%+v", v)
+}
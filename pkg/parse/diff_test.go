@@ -0,0 +1,77 @@
+package parse
+
+import (
+	"encoding/json"
+	"net/netip"
+	"testing"
+)
+
+type diffConf struct {
+	Name    string                   `json:"name"`
+	Hosts   []string                 `json:"hosts"`
+	Redis   *envRedis                `json:"redis"`
+	Addr    netip.Addr               `json:"addr"`
+	Tenants map[string]templateRedis `json:"tenants"`
+	Loggers []templateLogger         `json:"loggers"`
+	Ignored string                   `json:"-"`
+}
+
+func TestDiff(t *testing.T) {
+	a := diffConf{
+		Name:    "app",
+		Hosts:   []string{"h1", "h2"},
+		Redis:   &envRedis{Host: "a", Port: 1},
+		Addr:    netip.MustParseAddr("10.0.0.1"),
+		Tenants: map[string]templateRedis{"a": {Host: "x"}, "b": {Host: "y"}},
+		Loggers: []templateLogger{{Name: "app", Level: "info"}},
+		Ignored: "a",
+	}
+	b := diffConf{
+		Name:    "app",
+		Hosts:   []string{"h1"},
+		Redis:   &envRedis{Host: "b", Port: 1},
+		Addr:    netip.MustParseAddr("10.0.0.2"),
+		Tenants: map[string]templateRedis{"a": {Host: "x"}, "c": {Host: "z"}},
+		Loggers: []templateLogger{{Name: "app", Level: "debug"}},
+		Ignored: "b",
+	}
+	changes, err := Diff(&a, b, SetIdent(JSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `- hosts.1: "h2"
+~ redis.host: "a" -> "b"
+~ addr: 10.0.0.1 -> 10.0.0.2
+- tenants.b: {Host:y}
++ tenants.c: {Host:z}
+~ loggers.0.level: "info" -> "debug"`
+	if changes.String() != want {
+		t.Errorf("got:\n%v\nwant:\n%v", changes, want)
+	}
+
+	content, err := changes.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded []map[string]interface{}
+	if err := json.Unmarshal(content, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != 6 || decoded[1]["path"] != "redis.host" || decoded[1]["kind"] != "changed" ||
+		decoded[1]["old"] != "a" || decoded[1]["new"] != "b" || decoded[0]["new"] != nil {
+		t.Errorf("json: %s", content)
+	}
+
+	if changes, err := Diff(a, a); err != nil || len(changes) != 0 {
+		t.Errorf("same: %v, %v", changes, err)
+	}
+	if content, _ := Changes(nil).JSON(); string(content) != "[]\n" {
+		t.Errorf("empty json: %s", content)
+	}
+	if _, err := Diff(a, templateConf{}); err == nil {
+		t.Error("different types should fail")
+	}
+	if _, err := Diff(1, 2); err == nil {
+		t.Error("non struct should fail")
+	}
+}
@@ -0,0 +1,161 @@
+package parse
+
+import (
+	"bufio"
+	"bytes"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// docPosition is the position of a key in a config file.
+type docPosition struct {
+	Line   int
+	Column int
+}
+
+// documentIndex returns the position of every key in the content by its
+// dotted path, slice elements are indexed by their index, e.g. l.0.name.
+func documentIndex(content []byte, format string) (map[string]docPosition, error) {
+	index := make(map[string]docPosition)
+	if format == TOML {
+		indexTOML(content, index)
+		return index, nil
+	}
+	// JSON is parsed as YAML, which is a superset of it.
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		return nil, err
+	}
+	indexYAML(&root, nil, index)
+	return index, nil
+}
+
+func indexYAML(node *yaml.Node, parts []string, index map[string]docPosition) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			indexYAML(child, parts, index)
+		}
+	case yaml.AliasNode:
+		if node.Alias != nil {
+			indexYAML(node.Alias, parts, index)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Tag == "!!merge" {
+				indexYAML(value, parts, index)
+				continue
+			}
+			childParts := append(append([]string(nil), parts...), key.Value)
+			index[strings.Join(childParts, ".")] = docPosition{Line: key.Line, Column: key.Column}
+			indexYAML(value, childParts, index)
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			childParts := append(append([]string(nil), parts...), strconv.Itoa(i))
+			index[strings.Join(childParts, ".")] = docPosition{Line: child.Line, Column: child.Column}
+			indexYAML(child, childParts, index)
+		}
+	}
+}
+
+// indexTOML scans the tables and the keys of a TOML document line by line,
+// values spanning several lines are skipped.
+func indexTOML(content []byte, index map[string]docPosition) {
+	var (
+		table    []string
+		arrays   = make(map[string]int) // [[table]] => count
+		depth    int                    // depth of multi-line arrays and inline tables
+		multiStr string                 // delimiter of the current multi-line string
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		raw := scanner.Text()
+		line := strings.TrimSpace(raw)
+		column := len(raw) - len(strings.TrimLeft(raw, " \t")) + 1
+		if multiStr != "" {
+			if strings.Contains(line, multiStr) {
+				multiStr = ""
+			}
+			continue
+		}
+		if depth > 0 {
+			depth += tomlDepth(line)
+			continue
+		}
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+		case strings.HasPrefix(line, "[["):
+			end := strings.Index(line, "]]")
+			if end < 0 {
+				continue
+			}
+			name := strings.Join(splitTOMLKey(line[2:end]), ".")
+			table = append(splitTOMLKey(name), strconv.Itoa(arrays[name]))
+			arrays[name]++
+			index[name] = docPosition{Line: lineNo, Column: column}
+			index[strings.Join(table, ".")] = docPosition{Line: lineNo, Column: column}
+		case strings.HasPrefix(line, "["):
+			end := strings.Index(line, "]")
+			if end < 0 {
+				continue
+			}
+			table = splitTOMLKey(line[1:end])
+			index[strings.Join(table, ".")] = docPosition{Line: lineNo, Column: column}
+		default:
+			eq := strings.Index(line, "=")
+			if eq < 0 {
+				continue
+			}
+			parts := append(append([]string(nil), table...), splitTOMLKey(line[:eq])...)
+			index[strings.Join(parts, ".")] = docPosition{Line: lineNo, Column: column}
+			value := strings.TrimSpace(line[eq+1:])
+			for _, delim := range []string{`"""`, `'''`} {
+				if strings.HasPrefix(value, delim) && !strings.Contains(value[len(delim):], delim) {
+					multiStr = delim
+				}
+			}
+			if multiStr == "" {
+				depth = tomlDepth(value)
+			}
+		}
+	}
+}
+
+// splitTOMLKey splits a dotted TOML key, quotes around the parts are removed.
+func splitTOMLKey(key string) []string {
+	parts := strings.Split(key, ".")
+	for i, part := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(part), `"'`)
+	}
+	return parts
+}
+
+// tomlDepth returns the number of unclosed brackets and braces in s, brackets
+// in strings and comments are ignored.
+func tomlDepth(s string) int {
+	var (
+		depth int
+		quote rune
+	)
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return depth
+		case r == '[' || r == '{':
+			depth++
+		case r == ']' || r == '}':
+			depth--
+		}
+	}
+	return depth
+}
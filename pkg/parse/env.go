@@ -0,0 +1,115 @@
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// LoadEnv overrides the fields of the source with env, the env name of a field
+// is derived from its full ID, e.g. APP_REDIS_PORT for redis.port with the
+// prefix APP. Fields without env are not touched.
+func (p *parser) LoadEnv() error {
+	rv, err := p.sourceValue()
+	if err != nil {
+		return err
+	}
+	_, allFields, err := inspectField(rv, nil, p.tagOpt)
+	if err != nil {
+		return err
+	}
+	for _, field := range allFields {
+		if field.isParent || !field.canSet {
+			continue
+		}
+		name := p.envName(field)
+		s, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := p.setFieldByString(field, s); err != nil {
+			return fmt.Errorf("error parsing env %v for %v: %v", name, field.fullID(), err)
+		}
+		p.setOrigin(field, Origin{Source: SourceEnv, Key: name})
+	}
+	return nil
+}
+
+// envName returns the env name of the field, the prefix and the idents are
+// upper cased and joined by the env separator.
+func (p *parser) envName(field *parseField) string {
+	parts := make([]string, 0, len(field.fullIDParts)+1)
+	if p.envPrefix != "" {
+		parts = append(parts, p.envPrefix)
+	}
+	parts = append(parts, field.fullIDParts...)
+	for i, part := range parts {
+		parts[i] = strings.Map(func(r rune) rune {
+			switch {
+			case 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+				return r
+			case 'a' <= r && r <= 'z':
+				return r - 'a' + 'A'
+			}
+			return '_'
+		}, part)
+	}
+	return strings.Join(parts, p.envSeparator)
+}
+
+// sourceValue returns the struct the source points to.
+func (p *parser) sourceValue() (reflect.Value, error) {
+	if p.source == nil {
+		return reflect.Value{}, errors.New("source is nil, call InspectStruct first")
+	}
+	rv := reflect.ValueOf(p.source)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, errors.New("source must be a pointer to a struct")
+	}
+	return rv.Elem(), nil
+}
+
+// setFieldByString parses s into the field, nil pointers are allocated first.
+// Slices and maps are merged by their merge tag or SetSliceMerge.
+func (p *parser) setFieldByString(field *parseField, s string) error {
+	v := field.value
+	for v.Kind() == reflect.Ptr && !v.Type().Implements(typeOfTextUnmarshaler) {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Map && v.Kind() != reflect.Slice {
+		return p.setValueByTag(v, s, field.tagValue)
+	}
+	rule, err := p.mergeRule(field)
+	if err != nil {
+		return err
+	}
+	if isMap(v) {
+		old := reflect.New(v.Type()).Elem()
+		old.Set(v)
+		if err := p.parseMap(field, v, s); err != nil {
+			return err
+		}
+		if rule.mode != MergeReplace && !old.IsNil() {
+			iter := old.MapRange()
+			for iter.Next() {
+				if !v.MapIndex(iter.Key()).IsValid() {
+					v.SetMapIndex(iter.Key(), iter.Value())
+				}
+			}
+		}
+		return nil
+	}
+	old := copySlice(v)
+	if err := p.setValueByTag(v, s, field.tagValue); err != nil {
+		return err
+	}
+	if rule.mode == MergeReplace || old.Len() == 0 {
+		return nil
+	}
+	return p.mergeSlice(field, v, old, nil)
+}
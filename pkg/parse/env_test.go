@@ -0,0 +1,69 @@
+package parse
+
+import (
+	"net/netip"
+	"testing"
+)
+
+type envConf struct {
+	Name  **string          `yaml:"name" default:"app"`
+	Hosts []string          `yaml:"hosts" default:"a,b"`
+	Ports map[int]string    `yaml:"ports"`
+	Addr  netip.Addr        `yaml:"addr"`
+	Redis *envRedis         `yaml:"redis"`
+	Tags  map[string]string `yaml:"tags,omitempty"`
+}
+
+type envRedis struct {
+	Host string `yaml:"host" default:"127.0.0.1"`
+	Port int    `yaml:"port" default:"6379"`
+}
+
+func TestLoadEnv(t *testing.T) {
+	t.Setenv("APP_NAME", "demo")
+	t.Setenv("APP_HOSTS", "h1,h2,h3")
+	t.Setenv("APP_PORTS", "80,443")
+	t.Setenv("APP_ADDR", "10.0.0.1")
+	t.Setenv("APP_REDIS_PORT", "6380")
+	t.Setenv("APP_TAGS", "x")
+
+	c := envConf{}
+	p := NewParser(SetIdent(YAML), SetEnvPrefix("APP"))
+	if err := p.InspectStruct(&c); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.LoadEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if **c.Name != "demo" {
+		t.Errorf("name: %v", **c.Name)
+	}
+	if len(c.Hosts) != 3 || c.Hosts[2] != "h3" {
+		t.Errorf("hosts: %v", c.Hosts)
+	}
+	if _, ok := c.Ports[443]; !ok || len(c.Ports) != 2 {
+		t.Errorf("ports: %v", c.Ports)
+	}
+	if c.Addr.String() != "10.0.0.1" {
+		t.Errorf("addr: %v", c.Addr)
+	}
+	if c.Redis.Host != "127.0.0.1" || c.Redis.Port != 6380 {
+		t.Errorf("redis: %+v", *c.Redis)
+	}
+	if _, ok := c.Tags["x"]; !ok {
+		t.Errorf("tags: %v", c.Tags)
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	p := newDefaultParse()
+	field := &parseField{fullIDParts: []string{"log_Map2", "white-IP"}}
+	if name := p.envName(field); name != "LOG_MAP2_WHITE_IP" {
+		t.Errorf("name without prefix: %v", name)
+	}
+	SetEnvPrefix("app")(p)
+	SetEnvSeparator("__")(p)
+	if name := p.envName(field); name != "APP__LOG_MAP2__WHITE_IP" {
+		t.Errorf("name with prefix: %v", name)
+	}
+}
@@ -0,0 +1,173 @@
+package parse
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Stdin is the file name of stdin for ImportFile.
+const Stdin = "-"
+
+// UnknownFormatError is returned for a file whose format is neither known by
+// its extension, nor set by SetIdent, nor sniffed from its content.
+type UnknownFormatError struct {
+	Path string // file path, "" for Load
+}
+
+func (e *UnknownFormatError) Error() string {
+	if e.Path == "" {
+		return "unknown config format"
+	}
+	return fmt.Sprintf("unknown config format of %v", e.Path)
+}
+
+// fileFormat is the format of a config file.
+type fileFormat struct {
+	name    string // yaml, json or toml
+	decoder UnmarshalFunc
+	encoder MarshalFunc
+}
+
+var (
+	yamlFormat = fileFormat{name: YAML, decoder: YAMLDecoder, encoder: YAMLEncoder}
+	jsonFormat = fileFormat{name: JSON, decoder: JSONDecoder, encoder: JSONEncoder}
+	tomlFormat = fileFormat{name: TOML, decoder: TOMLDecoder, encoder: TOMLEncoder}
+)
+
+// formatByName returns the format of yaml, json or toml.
+func formatByName(name string) (fileFormat, bool) {
+	switch name {
+	case YAML:
+		return yamlFormat, true
+	case JSON:
+		return jsonFormat, true
+	case TOML:
+		return tomlFormat, true
+	}
+	return fileFormat{}, false
+}
+
+// formatByExt returns the format of a file by its extension, false if the
+// extension is unknown.
+func formatByExt(path string) (fileFormat, bool) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return yamlFormat, true
+	case ".json":
+		return jsonFormat, true
+	case ".toml":
+		return tomlFormat, true
+	}
+	return fileFormat{}, false
+}
+
+// sniffFormat guesses the format of content: JSON starts with { or [, TOML
+// starts with a [table] or a key = value line, the others are YAML mappings.
+func sniffFormat(content []byte) (fileFormat, bool) {
+	text := strings.TrimSpace(string(content))
+	if strings.HasPrefix(text, "{") {
+		return jsonFormat, true
+	}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if tomlLine.MatchString(line) {
+			return tomlFormat, true
+		}
+		break
+	}
+	var doc map[string]interface{}
+	if err := YAMLDecoder(content, &doc); err == nil {
+		return yamlFormat, true
+	}
+	return fileFormat{}, false
+}
+
+// tomlLine matches the first line of a TOML document.
+var tomlLine = regexp.MustCompile(`^(\[\[?[\w.\-" ]+\]\]?|[\w.\-"]+\s*=)`)
+
+// detectFormat returns the format of a file: by its extension, else set by
+// SetIdent, else sniffed from its content.
+func (p *parser) detectFormat(path string, content []byte) (fileFormat, error) {
+	if f, ok := formatByExt(path); ok {
+		return f, nil
+	}
+	if p.decoder != nil {
+		f, _ := formatByName(p.tagOpt.IdentTag)
+		f.decoder, f.encoder = p.decoder, p.encoder
+		return f, nil
+	}
+	if f, ok := sniffFormat(content); ok {
+		return f, nil
+	}
+	return fileFormat{}, &UnknownFormatError{Path: path}
+}
+
+// ImportFiles imports files, globs and directories in the order given, e.g.
+//
+//	p.ImportFiles("config.yaml", "conf.d")
+//
+// The files of a glob or a directory are imported in lexical order; a
+// directory only imports its .yaml, .yml, .json and .toml files, not its
+// subdirectories. The format of a file is detected like ImportFile. Each file
+// is merged into the source, the later file wins. A glob matching no file is
+// an error.
+func (p *parser) ImportFiles(patterns ...string) error {
+	for _, pattern := range patterns {
+		files, err := expandFiles(pattern)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			if err := p.ImportFile(file); err != nil {
+				return fmt.Errorf("import %v: %w", file, err)
+			}
+		}
+	}
+	return nil
+}
+
+// expandFiles returns the files of a file, a glob or a directory.
+func expandFiles(pattern string) ([]string, error) {
+	info, err := os.Stat(pattern)
+	if err == nil && info.IsDir() {
+		entries, err := os.ReadDir(pattern)
+		if err != nil {
+			return nil, err
+		}
+		var files []string
+		for _, entry := range entries {
+			if _, ok := formatByExt(entry.Name()); ok && !entry.IsDir() {
+				files = append(files, filepath.Join(pattern, entry.Name()))
+			}
+		}
+		return files, nil // ReadDir sorts by name
+	}
+	if err == nil {
+		return []string{pattern}, nil
+	}
+	files, globErr := filepath.Glob(pattern)
+	if globErr != nil {
+		return nil, globErr
+	}
+	if len(files) == 0 {
+		if !os.IsNotExist(err) || !strings.ContainsAny(pattern, "*?[") {
+			return nil, err
+		}
+		return nil, fmt.Errorf("no files match %v", pattern)
+	}
+	sort.Strings(files)
+	var regular []string
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			regular = append(regular, file)
+		}
+	}
+	return regular, nil
+}
@@ -0,0 +1,168 @@
+package parse
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+type filesConf struct {
+	Name  string         `yaml:"name" json:"name" toml:"name"`
+	Hosts []string       `yaml:"hosts" json:"hosts" toml:"hosts" merge:"append"`
+	Redis filesRedisConf `yaml:"redis" json:"redis" toml:"redis"`
+}
+
+type filesRedisConf struct {
+	Host string `yaml:"host" json:"host" toml:"host"`
+	Port int    `yaml:"port" json:"port" toml:"port"`
+	DB   int    `yaml:"db" json:"db" toml:"db"`
+}
+
+func TestImportFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"config.yaml":          "name: app\nhosts: [a]\nredis:\n  host: redis\n  port: 6379\n",
+		"conf.d/20-port.json":  `{"redis": {"port": 6380}, "hosts": ["c"]}`,
+		"conf.d/10-db.yaml":    "redis:\n  db: 7\nhosts: [b]\n",
+		"conf.d/30-name.toml":  "name = \"toml\"\n",
+		"conf.d/README.md":     "not a config",
+		"conf.d/sub/99.yaml":   "name: sub\n",
+		"extra/1-local.yaml":   "redis:\n  host: local\n",
+		"extra/2-ignored.json": `{"name": "glob"}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var c filesConf
+	p := NewParser(SetIdent(YAML))
+	if err := p.InspectStruct(&c); err != nil {
+		t.Fatal(err)
+	}
+	err := p.ImportFiles(filepath.Join(dir, "config.yaml"), filepath.Join(dir, "conf.d"), filepath.Join(dir, "extra", "*.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := filesConf{
+		Name:  "toml",
+		Hosts: []string{"a", "b", "c"},
+		Redis: filesRedisConf{Host: "local", Port: 6380, DB: 7},
+	}
+	if !reflect.DeepEqual(c, want) {
+		t.Fatalf("got %+v, want %+v", c, want)
+	}
+	if o, ok := p.Explain("redis.port"); !ok || o.Path != filepath.Join(dir, "conf.d", "20-port.json") {
+		t.Fatalf("origin of redis.port %v", o)
+	}
+
+	if err := p.ImportFiles(filepath.Join(dir, "missing", "*.yaml")); err == nil {
+		t.Fatal("glob matching no file accepted")
+	}
+	if err := p.ImportFiles(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Fatal("missing file accepted")
+	}
+}
+
+func TestImportFileFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"conf.json", `{"name": "json", "redis": {"port": 1}}`},
+		{"conf.toml", "name = \"toml\"\n[redis]\nport = 1\n"},
+		{"conf.yml", "name: yml\nredis:\n  port: 1\n"},
+		{"json.conf", `{"name": "json", "redis": {"port": 1}}`},
+		{"toml.conf", "# comment\n\n[redis]\nport = 1\n"},
+		{"yaml.conf", "redis:\n  port: 1\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, tt.name, tt.content)
+			var c filesConf
+			p := NewParser() // no SetIdent, the format is detected
+			if err := p.InspectStruct(&c); err != nil {
+				t.Fatal(err)
+			}
+			if err := p.ImportFile(path); err != nil {
+				t.Fatal(err)
+			}
+			if c.Redis.Port != 1 {
+				t.Fatalf("got %+v", c)
+			}
+		})
+	}
+
+	var c filesConf
+	p := NewParser()
+	if err := p.InspectStruct(&c); err != nil {
+		t.Fatal(err)
+	}
+	var formatErr *UnknownFormatError
+	if err := p.ImportFile(writeFile(t, "conf.txt", "just some text")); !errors.As(err, &formatErr) {
+		t.Fatalf("unknown format: %v", err)
+	}
+	if err := p.Load([]byte("just some text")); !errors.As(err, &formatErr) {
+		t.Fatalf("unknown format: %v", err)
+	}
+	if err := p.ExportFile(filepath.Join(t.TempDir(), "conf.txt")); !errors.As(err, &formatErr) {
+		t.Fatalf("unknown export format: %v", err)
+	}
+}
+
+func TestImportFileStdin(t *testing.T) {
+	stdin := writeFile(t, "stdin", "name = \"stdin\"\n")
+	f, err := os.Open(stdin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	old := os.Stdin
+	os.Stdin = f
+	defer func() { os.Stdin = old }()
+
+	var c filesConf
+	p := NewParser()
+	if err := p.InspectStruct(&c); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.ImportFile(Stdin); err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "stdin" {
+		t.Fatalf("got %+v", c)
+	}
+}
+
+func TestExportFileFormat(t *testing.T) {
+	c := filesConf{Name: "app", Hosts: []string{"a"}, Redis: filesRedisConf{Port: 1}}
+	p := NewParser(SetIdent(JSON)) // keys by json tags whatever the format
+	if err := p.InspectStruct(&c); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	for _, name := range []string{"conf.yaml", "conf.toml", "conf.json"} {
+		path := filepath.Join(dir, name)
+		if err := p.ExportFile(path); err != nil {
+			t.Fatal(err)
+		}
+		var got filesConf
+		q := NewParser()
+		if err := q.InspectStruct(&got); err != nil {
+			t.Fatal(err)
+		}
+		if err := q.ImportFile(path); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, c) {
+			t.Fatalf("%v: got %+v, want %+v", name, got, c)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package parse
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/asppj/goload/internal/conv"
+)
+
+var (
+	typeOfDuration = reflect.TypeOf(time.Duration(0))
+	typeOfTime     = reflect.TypeOf(time.Time{})
+)
+
+// hasFormat reports whether the values of the field are formatted by its
+// layout or unit tag.
+func hasFormat(tag TagValue) bool {
+	return tag.Layout != "" || tag.Unit == conv.UnitBytes
+}
+
+// setValueByTag sets v by parsing s with the layout tag for time.Time values
+// and the unit tag for ints, pointers to and slices of them included, see
+// conv.TimeLayout and conv.ByteSize.
+func (p *parser) setValueByTag(v reflect.Value, s string, tag TagValue) error {
+	if !hasFormat(tag) {
+		return p.setValueByString(v, s)
+	}
+	switch {
+	case v.Type() == typeOfTime && tag.Layout != "":
+		t, err := conv.ParseTime(s, tag.Layout)
+		if err != nil {
+			return parseError(s, v.Type(), err)
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	case isInt(v) && tag.Unit == conv.UnitBytes:
+		if err := conv.SetByteSize(v, s); err != nil {
+			return parseError(s, v.Type(), err)
+		}
+		return nil
+	case v.Kind() == reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return p.setValueByTag(v.Elem(), s, tag)
+	case isSlice(v):
+		vals, err := readAsCSV(s)
+		if err != nil {
+			return fmt.Errorf("failed to parse slice value: %v", err)
+		}
+		slice := reflect.MakeSlice(v.Type(), len(vals), len(vals))
+		for i := range vals {
+			if err := p.setValueByTag(slice.Index(i), vals[i], tag); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	}
+	return p.setValueByString(v, s)
+}
+
+// isInt reports whether v is an int or uint, not a TextUnmarshaler such as
+// conv.ByteSize which parses itself.
+func isInt(v reflect.Value) bool {
+	return (v.CanInt() || v.CanUint()) && !isTextUnmarshaler(v.Type())
+}
+
+// fromGenericTag is fromGeneric for a field with a layout or unit tag, the
+// strings of the file are parsed by setValueByTag.
+func (p *parser) fromGenericTag(v reflect.Value, data interface{}, parts []string, tag TagValue) error {
+	switch d := data.(type) {
+	case string:
+		if err := p.setValueByTag(v, d, tag); err != nil {
+			return fmt.Errorf("%v: %v", strings.Join(parts, "."), err)
+		}
+		return nil
+	case []interface{}:
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			return p.fromGenericTag(v.Elem(), data, parts, tag)
+		}
+		if v.Kind() != reflect.Slice {
+			break
+		}
+		elems := reflect.MakeSlice(v.Type(), len(d), len(d))
+		for i, value := range d {
+			if err := p.fromGenericTag(elems.Index(i), value, appendPart(parts, fmt.Sprint(i)), tag); err != nil {
+				return err
+			}
+		}
+		v.Set(elems)
+		return nil
+	}
+	return p.fromGeneric(v, data, parts)
+}
+
+// toGenericTag is toGeneric for a field with a layout or unit tag, time.Time
+// values are formatted with the layout and ints as a conv.ByteSize.
+func (p *parser) toGenericTag(v reflect.Value, tag TagValue) interface{} {
+	if !hasFormat(tag) {
+		return p.toGeneric(v)
+	}
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	switch {
+	case v.Type() == typeOfTime && tag.Layout != "":
+		return v.Interface().(time.Time).Format(conv.TimeLayout(tag.Layout))
+	case isInt(v) && tag.Unit == conv.UnitBytes:
+		if v.CanUint() {
+			return conv.FormatUint(v.Uint())
+		}
+		return conv.ByteSize(v.Int()).String()
+	case v.Kind() == reflect.Slice && !v.IsNil() && v.Type() != typeOfByteSlice:
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			out[i] = p.toGenericTag(v.Index(i), tag)
+		}
+		return out
+	}
+	return p.toGeneric(v)
+}
+
+// fieldTag returns the tags of the field of the struct t at index.
+func (p *parser) fieldTag(t reflect.Type, index []int) TagValue {
+	return parseFromField(t.FieldByIndex(index), nil, p.tagOpt).tagValue
+}
@@ -0,0 +1,319 @@
+package parse
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asppj/goload/internal/conv"
+)
+
+type timeConf struct {
+	Timeout  time.Duration   `yaml:"timeout" default:"5s"`
+	Retain   time.Duration   `yaml:"retain" default:"1w2d"`
+	Backoff  []time.Duration `yaml:"backoff" default:"1s,1m,1.5d"`
+	Start    time.Time       `yaml:"start" default:"2024-01-02T03:04:05Z"`
+	Day      time.Time       `yaml:"day" layout:"DateOnly" default:"2024-03-01"`
+	Holidays []time.Time     `yaml:"holidays" layout:"02/01/2006" default:"25/12/2024,01/01/2025"`
+	Expire   *time.Time      `yaml:"expire" layout:"2006-01-02 15:04"`
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestTimeDefaults(t *testing.T) {
+	c := timeConf{}
+	if err := NewParser().InspectStruct(&c); err != nil {
+		t.Fatal(err)
+	}
+	want := timeConf{
+		Timeout:  5 * time.Second,
+		Retain:   9 * 24 * time.Hour,
+		Backoff:  []time.Duration{time.Second, time.Minute, 36 * time.Hour},
+		Start:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Day:      date(2024, 3, 1),
+		Holidays: []time.Time{date(2024, 12, 25), date(2025, 1, 1)},
+		Expire:   &time.Time{},
+	}
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("defaults:\n got %+v\nwant %+v", c, want)
+	}
+
+	type invalid struct {
+		Day time.Time `layout:"DateOnly" default:"2024-03-01T00:00:00Z"`
+	}
+	if err := NewParser().InspectStruct(&invalid{}); err == nil {
+		t.Error("a default not in the layout should fail")
+	}
+}
+
+func TestTimeEnvAndCmd(t *testing.T) {
+	t.Setenv("APP_TIMEOUT", "2m")
+	t.Setenv("APP_HOLIDAYS", "14/07/2025")
+	c := timeConf{}
+	p := NewParser(SetEnvPrefix("APP"), SetArgs([]string{"--retain=3d", "--expire=2025-06-30 18:00"}))
+	if err := p.InspectStruct(&c); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.LoadEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.LoadCmd(); err != nil {
+		t.Fatal(err)
+	}
+	if c.Timeout != 2*time.Minute || c.Retain != 72*time.Hour {
+		t.Errorf("durations: %v %v", c.Timeout, c.Retain)
+	}
+	if !reflect.DeepEqual(c.Holidays, []time.Time{date(2025, 7, 14)}) {
+		t.Errorf("holidays: %v", c.Holidays)
+	}
+	if c.Expire == nil || !c.Expire.Equal(time.Date(2025, 6, 30, 18, 0, 0, 0, time.UTC)) {
+		t.Errorf("expire: %v", c.Expire)
+	}
+
+	p = NewParser(SetArgs([]string{"--timeout=5x"}))
+	if err := p.InspectStruct(&timeConf{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.LoadCmd(); err == nil {
+		t.Error("an invalid duration should fail")
+	}
+}
+
+func TestTimeFiles(t *testing.T) {
+	files := map[string]string{
+		"conf.yaml": `timeout: 1h30m
+retain: 2w
+backoff: [100ms, 1d]
+start: 2025-01-02T03:04:05Z
+day: 2025-02-03
+holidays: [24/12/2025]
+expire: 2025-06-30 18:00
+`,
+		"conf.json": `{"timeout": "1h30m", "retain": "2w", "backoff": ["100ms", "1d"],
+"start": "2025-01-02T03:04:05Z", "day": "2025-02-03", "holidays": ["24/12/2025"],
+"expire": "2025-06-30 18:00"}`,
+		"conf.toml": `timeout = "1h30m"
+retain = "2w"
+backoff = ["100ms", "1d"]
+start = 2025-01-02T03:04:05Z
+day = "2025-02-03"
+holidays = ["24/12/2025"]
+expire = "2025-06-30 18:00"
+`,
+	}
+	want := timeConf{
+		Timeout:  90 * time.Minute,
+		Retain:   14 * 24 * time.Hour,
+		Backoff:  []time.Duration{100 * time.Millisecond, 24 * time.Hour},
+		Start:    time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		Day:      date(2025, 2, 3),
+		Holidays: []time.Time{date(2025, 12, 24)},
+	}
+	expire := time.Date(2025, 6, 30, 18, 0, 0, 0, time.UTC)
+	want.Expire = &expire
+	for name, content := range files {
+		path := writeFile(t, name, content)
+		c := timeConf{}
+		p := NewParser(SetStrict(true))
+		if err := p.InspectStruct(&c); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.ImportFile(path); err != nil {
+			t.Fatalf("%v: %v", name, err)
+		}
+		if !c.Start.Equal(want.Start) {
+			t.Errorf("%v: start %v", name, c.Start)
+		}
+		c.Start = want.Start
+		if !reflect.DeepEqual(c, want) {
+			t.Errorf("%v:\n got %+v\nwant %+v", name, c, want)
+		}
+	}
+
+	path := writeFile(t, "invalid.yaml", "timeout: 5x\n")
+	p := NewParser(SetStrict(true))
+	if err := p.InspectStruct(&timeConf{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.ImportFile(path); err == nil {
+		t.Error("an invalid duration should fail in strict mode")
+	}
+}
+
+func TestTimeExport(t *testing.T) {
+	c := timeConf{}
+	p := NewParser()
+	if err := p.InspectStruct(&c); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "conf.yaml")
+	if err := p.ExportFile(path); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"timeout: 5s", "retain: 216h0m0s", "day: \"2024-03-01\"", "- 25/12/2024"} {
+		if !strings.Contains(string(content), line) {
+			t.Errorf("export should contain %q:\n%s", line, content)
+		}
+	}
+
+	loaded := timeConf{}
+	p = NewParser()
+	if err := p.InspectStruct(&loaded); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.ImportFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, c) {
+		t.Errorf("round trip:\n got %+v\nwant %+v", loaded, c)
+	}
+}
+
+type sizeConf struct {
+	MaxBody  int64         `yaml:"max_body" unit:"bytes" default:"10MB"`
+	Buffer   conv.ByteSize `yaml:"buffer" default:"512k"`
+	Cache    *uint32       `yaml:"cache" unit:"bytes" default:"1.5GiB"`
+	Chunks   []int         `yaml:"chunks" unit:"bytes" default:"4KiB,1m"`
+	Requests int           `yaml:"requests" default:"100"`
+}
+
+func TestByteSize(t *testing.T) {
+	c := sizeConf{}
+	if err := NewParser().InspectStruct(&c); err != nil {
+		t.Fatal(err)
+	}
+	cache := uint32(1536 * conv.MiB)
+	want := sizeConf{
+		MaxBody:  10_000_000,
+		Buffer:   512 * conv.KiB,
+		Cache:    &cache,
+		Chunks:   []int{4096, 1 << 20},
+		Requests: 100,
+	}
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("defaults:\n got %+v\nwant %+v", c, want)
+	}
+
+	t.Setenv("APP_MAX_BODY", "2MiB")
+	p := NewParser(SetEnvPrefix("APP"), SetArgs([]string{"--buffer=1GB", "--chunks=1k"}))
+	if err := p.InspectStruct(&c); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.LoadEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.LoadCmd(); err != nil {
+		t.Fatal(err)
+	}
+	if c.MaxBody != 2<<20 || c.Buffer != conv.GB || !reflect.DeepEqual(c.Chunks, []int{1024}) {
+		t.Errorf("env and flags: %+v", c)
+	}
+
+	type small struct {
+		Size uint8 `unit:"bytes" default:"1k"`
+	}
+	if err := NewParser().InspectStruct(&small{}); err == nil {
+		t.Error("a size overflowing the int should fail")
+	}
+}
+
+func TestByteSizeFiles(t *testing.T) {
+	path := writeFile(t, "conf.yaml", "max_body: 1.5MB\nbuffer: 64KiB\ncache: 1048576\nchunks: [1k, 2048]\n")
+	c := sizeConf{}
+	p := NewParser(SetStrict(true))
+	if err := p.InspectStruct(&c); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.ImportFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if c.MaxBody != 1_500_000 || c.Buffer != 64*conv.KiB || *c.Cache != 1<<20 || !reflect.DeepEqual(c.Chunks, []int{1024, 2048}) {
+		t.Errorf("file: %+v", c)
+	}
+
+	out := filepath.Join(t.TempDir(), "conf.json")
+	if err := p.ExportFile(out); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{`"max_body": "1500KB"`, `"buffer": "64KiB"`, `"cache": "1MiB"`, `"1KiB"`, `"2KiB"`, `"requests": 100`} {
+		if !strings.Contains(string(content), s) {
+			t.Errorf("export should contain %v:\n%s", s, content)
+		}
+	}
+
+	for name, want := range map[string]string{"conf.yaml": "max_body: 1500KB", "conf.toml": "max_body = '1500KB'"} {
+		out := filepath.Join(t.TempDir(), name)
+		if err := p.ExportTemplate(out); err != nil {
+			t.Fatal(err)
+		}
+		content, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(content), want) {
+			t.Errorf("template should contain %v:\n%s", want, content)
+		}
+	}
+
+	path = writeFile(t, "invalid.yaml", "max_body: 10XB\n")
+	p = NewParser(SetStrict(true))
+	if err := p.InspectStruct(&sizeConf{}); err != nil {
+		t.Fatal(err)
+	}
+	err = p.ImportFile(path)
+	var errs ValidationErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Field != "max_body" {
+		t.Errorf("an invalid size should fail in strict mode: %v", err)
+	}
+}
+
+func TestByteSizeUint64(t *testing.T) {
+	type bigConf struct {
+		Limit uint64 `yaml:"limit" unit:"bytes" default:"18446744073709551615"`
+		Quota uint64 `yaml:"quota" unit:"bytes" default:"16383PiB"`
+	}
+	c := bigConf{}
+	p := NewParser()
+	if err := p.InspectStruct(&c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Limit != math.MaxUint64 || c.Quota != (1<<14-1)<<50 {
+		t.Fatalf("defaults: %+v", c)
+	}
+	out := filepath.Join(t.TempDir(), "conf.yaml")
+	if err := p.ExportFile(out); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"limit: 18446744073709551615B", "quota: 16383PiB"} {
+		if !strings.Contains(string(content), s) {
+			t.Errorf("export should contain %v:\n%s", s, content)
+		}
+	}
+	exported := bigConf{}
+	p = NewParser(SetStrict(true))
+	if err := p.InspectStruct(&exported); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.ImportFile(out); err != nil || exported != c {
+		t.Errorf("round trip: %+v, %v", exported, err)
+	}
+}
@@ -0,0 +1,266 @@
+package parse
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var typeOfTextMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// toGeneric converts v into maps, slices and scalars as decoded from a file,
+// the fields of structs are keyed by their ident. The fields of embedded
+// structs without ident are inlined like encoding/json, the outer fields win.
+func (p *parser) toGeneric(v reflect.Value) interface{} {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil
+	}
+	if text, ok := marshalText(v); ok {
+		return text
+	}
+	if v.Type() == typeOfByteSlice {
+		return base64.StdEncoding.EncodeToString(v.Bytes())
+	}
+	if v.Type() == typeOfDuration {
+		return v.Interface().(time.Duration).String()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		out := make(map[string]interface{}, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if isInlined(field, p.tagOpt.IdentTag) {
+				embedded, _ := p.toGeneric(v.Field(i)).(map[string]interface{})
+				for key, value := range embedded {
+					if _, ok := out[key]; !ok {
+						out[key] = value
+					}
+				}
+				continue
+			}
+			ident := identFromField(field, p.tagOpt.IdentTag)
+			if !field.IsExported() || ident == "-" {
+				continue
+			}
+			out[ident] = p.toGenericTag(v.Field(i), p.fieldTag(v.Type(), []int{i}))
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			out[i] = p.toGeneric(v.Index(i))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		out := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out[fmt.Sprint(iter.Key())] = p.toGeneric(iter.Value())
+		}
+		return out
+	}
+	return v.Interface()
+}
+
+// isInlined reports whether the field is an exported embedded struct without
+// ident, whose fields are keyed as the fields of the outer struct like
+// encoding/json, e.g. mode for LocalConf.CommonConf.Mode.
+func isInlined(field reflect.StructField, identTag string) bool {
+	if !field.Anonymous || !field.IsExported() {
+		return false
+	}
+	if ident, _, _ := strings.Cut(field.Tag.Get(identTag), ","); ident != "" {
+		return false
+	}
+	t := field.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && !isTextUnmarshaler(t)
+}
+
+// marshalText returns the text of v by the encoder registered for its type,
+// or if v or *v implements encoding.TextMarshaler.
+func marshalText(v reflect.Value) (string, bool) {
+	if text, ok, err := encodeText(v); ok {
+		return text, err == nil
+	}
+	if !v.Type().Implements(typeOfTextMarshaler) {
+		if !reflect.PointerTo(v.Type()).Implements(typeOfTextMarshaler) {
+			return "", false
+		}
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		v = ptr
+	}
+	text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+	if err != nil {
+		return "", false
+	}
+	return string(text), true
+}
+
+// decodeGeneric decodes content into maps, slices and scalars, JSON numbers
+// are kept as json.Number not to lose the precision of large integers.
+func decodeGeneric(content []byte, f fileFormat) (map[string]interface{}, error) {
+	var doc map[string]interface{}
+	if f.name == JSON {
+		dec := json.NewDecoder(bytes.NewReader(content))
+		dec.UseNumber()
+		if err := dec.Decode(&doc); err != nil && err != io.EOF {
+			return nil, err
+		}
+		return doc, nil
+	}
+	if err := f.decoder(content, &doc); err != nil {
+		return nil, err
+	}
+	doc, _ = cleanUpYAML(doc).(map[string]interface{})
+	return doc, nil
+}
+
+// fromGeneric sets v from data decoded by decodeGeneric, the keys of structs
+// are routed by the idents of the fields, so one ident tag works for all the
+// formats. Only the keys in data are set: structs are merged field by field
+// and maps key by key, slices are replaced. Unknown keys are ignored, see
+// SetStrict.
+func (p *parser) fromGeneric(v reflect.Value, data interface{}, parts []string) error {
+	if data == nil {
+		switch v.Kind() {
+		case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+			v.Set(reflect.Zero(v.Type()))
+		}
+		return nil
+	}
+	dv := reflect.ValueOf(data)
+	if dv.Type().AssignableTo(v.Type()) {
+		v.Set(dv)
+		return nil
+	}
+	if isTextUnmarshaler(v.Type()) && v.Kind() != reflect.Ptr {
+		return p.fromGenericScalar(v, data, parts)
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return p.fromGeneric(v.Elem(), data, parts)
+	case reflect.Struct:
+		m, ok := data.(map[string]interface{})
+		if !ok {
+			return genericError(parts, data, v.Type())
+		}
+		return p.parseMapToStruct(reflect.ValueOf(m), v, parts)
+	case reflect.Map:
+		m, ok := data.(map[string]interface{})
+		if !ok {
+			return genericError(parts, data, v.Type())
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMapWithSize(v.Type(), len(m)))
+		}
+		for key, value := range m {
+			k := reflect.New(v.Type().Key()).Elem()
+			if err := p.parseSimpleValue(k, key); err != nil {
+				return fmt.Errorf("%v: invalid key %q: %v", strings.Join(parts, "."), key, err)
+			}
+			// an existing value is merged
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if old := v.MapIndex(k); old.IsValid() {
+				elem.Set(old)
+			}
+			if err := p.fromGeneric(elem, value, appendPart(parts, key)); err != nil {
+				return err
+			}
+			v.SetMapIndex(k, elem)
+		}
+		return nil
+	case reflect.Slice, reflect.Array:
+		if v.Type() == typeOfByteSlice {
+			s, ok := data.(string)
+			if !ok {
+				return genericError(parts, data, v.Type())
+			}
+			b, err := base64.StdEncoding.DecodeString(s)
+			if err != nil {
+				return fmt.Errorf("%v: %v", strings.Join(parts, "."), err)
+			}
+			v.SetBytes(b)
+			return nil
+		}
+		s, ok := data.([]interface{})
+		if !ok {
+			return p.fromGenericScalar(v, data, parts)
+		}
+		elems := v
+		if v.Kind() == reflect.Slice {
+			elems = reflect.MakeSlice(v.Type(), len(s), len(s))
+		} else if len(s) > v.Len() {
+			return fmt.Errorf("%v: %d elements for %v", strings.Join(parts, "."), len(s), v.Type())
+		}
+		for i, value := range s {
+			if err := p.fromGeneric(elems.Index(i), value, appendPart(parts, strconv.Itoa(i))); err != nil {
+				return err
+			}
+		}
+		v.Set(elems)
+		return nil
+	}
+	return p.fromGenericScalar(v, data, parts)
+}
+
+// fromGenericScalar sets the scalar v from a decoded scalar.
+func (p *parser) fromGenericScalar(v reflect.Value, data interface{}, parts []string) error {
+	var s string
+	switch d := data.(type) {
+	case string:
+		s = d
+	case bool, json.Number:
+		s = fmt.Sprint(d)
+	case float64:
+		if k := v.Kind(); k >= reflect.Int && k <= reflect.Uint64 && d != math.Trunc(d) {
+			return genericError(parts, data, v.Type())
+		}
+		s = strconv.FormatFloat(d, 'f', -1, 64)
+	case int, int64, uint64:
+		s = fmt.Sprint(d)
+	case map[string]interface{}, []interface{}:
+		return genericError(parts, data, v.Type())
+	default:
+		// e.g. time.Time or toml.LocalDate for a TextUnmarshaler
+		text, ok := marshalText(reflect.ValueOf(data))
+		if !ok {
+			return genericError(parts, data, v.Type())
+		}
+		s = text
+	}
+	if err := p.setValueByString(v, s); err != nil {
+		return fmt.Errorf("%v: %v", strings.Join(parts, "."), err)
+	}
+	return nil
+}
+
+func genericError(parts []string, data interface{}, t reflect.Type) error {
+	return fmt.Errorf("%v: cannot set %v to %v", strings.Join(parts, "."), docType(data), t)
+}
@@ -0,0 +1,157 @@
+package parse
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// identConf is only tagged with json, the names drive all the formats.
+type identConf struct {
+	*IdentCommon
+	Name    string                    `json:"app_name"`
+	ID      int64                     `json:"id"`
+	Servers map[string]identServer    `json:"servers"`
+	Hosts   []identServer             `json:"hosts"`
+	Data    []byte                    `json:"data"`
+	Skip    string                    `json:"-"`
+	Nested  *struct{ Level string }   `json:"nested"`
+	Limits  map[string]map[string]int `json:"limits"`
+}
+
+type IdentCommon struct {
+	Mode string `json:"mode"`
+	Name string `json:"app_name"` // shadowed by identConf.Name
+}
+
+type identServer struct {
+	Addr string `json:"addr"`
+	Port int    `json:"port"`
+}
+
+func TestImportFileIdent(t *testing.T) {
+	files := map[string]string{
+		"conf.yaml": `mode: prod
+app_name: app
+id: 9007199254740993
+servers:
+  a: {addr: a.local}
+hosts:
+  - {addr: h1, port: 1}
+data: aGk=
+nested: {level: info}
+limits: {a: {x: 1}}
+`,
+		"conf.toml": `mode = "prod"
+app_name = "app"
+id = 9007199254740993
+data = "aGk="
+[servers.a]
+addr = "a.local"
+[[hosts]]
+addr = "h1"
+port = 1
+[nested]
+level = "info"
+[limits.a]
+x = 1
+`,
+		"conf.json": `{"mode": "prod", "app_name": "app", "id": 9007199254740993,
+"servers": {"a": {"addr": "a.local"}}, "hosts": [{"addr": "h1", "port": 1}], "data": "aGk=",
+"nested": {"level": "info"}, "limits": {"a": {"x": 1}}}`,
+	}
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			path := writeFile(t, name, content)
+			c := identConf{Servers: map[string]identServer{"a": {Port: 80}}, Limits: map[string]map[string]int{"a": {"y": 2}}}
+			p := NewParser(SetIdent(JSON))
+			if err := p.InspectStruct(&c); err != nil {
+				t.Fatal(err)
+			}
+			if err := p.ImportFile(path); err != nil {
+				t.Fatal(err)
+			}
+			if c.IdentCommon == nil || c.Mode != "prod" || c.IdentCommon.Name != "" || c.Name != "app" {
+				t.Fatalf("inlined struct: %+v %+v", c.IdentCommon, c.Name)
+			}
+			if c.ID != 9007199254740993 || string(c.Data) != "hi" {
+				t.Fatalf("scalars: %v %q", c.ID, c.Data)
+			}
+			// maps are merged key by key, deeply
+			if want := (identServer{Addr: "a.local", Port: 80}); c.Servers["a"] != want {
+				t.Fatalf("servers %+v", c.Servers)
+			}
+			if want := map[string]int{"x": 1, "y": 2}; !reflect.DeepEqual(c.Limits["a"], want) {
+				t.Fatalf("limits %+v", c.Limits)
+			}
+			if want := []identServer{{Addr: "h1", Port: 1}}; !reflect.DeepEqual(c.Hosts, want) {
+				t.Fatalf("hosts %+v", c.Hosts)
+			}
+			if c.Nested.Level != "info" {
+				t.Fatalf("nested %+v", c.Nested)
+			}
+			if o, ok := p.Explain("mode"); !ok || o.Path != path {
+				t.Fatalf("origin of mode %v", o)
+			}
+		})
+	}
+}
+
+func TestImportFileIdentErrors(t *testing.T) {
+	tests := map[string]string{
+		"object for scalar": "id: {a: 1}\n",
+		"fraction for int":  "id: 1.5\n",
+		"scalar for struct": "nested: info\n",
+		"invalid map key":   "servers: [a]\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			var c identConf
+			p := NewParser(SetIdent(JSON))
+			if err := p.InspectStruct(&c); err != nil {
+				t.Fatal(err)
+			}
+			if err := p.ImportFile(writeFile(t, "conf.yaml", content)); err == nil {
+				t.Fatalf("%q accepted: %+v", content, c)
+			}
+		})
+	}
+}
+
+func TestIdentAllFormats(t *testing.T) {
+	c := identConf{IdentCommon: &IdentCommon{Mode: "prod"}, Name: "app", Hosts: []identServer{{Addr: "h1"}}}
+	p := NewParser(SetIdent(JSON))
+	if err := p.InspectStruct(&c); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "conf.yaml")
+	if err := p.ExportFile(path); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]interface{}
+	if err := YAMLDecoder(content, &doc); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"mode", "app_name", "hosts"} {
+		if _, ok := doc[key]; !ok {
+			t.Fatalf("key %v missing in\n%s", key, content)
+		}
+	}
+
+	t.Setenv("APP_MODE", "test")
+	t.Setenv("APP_APP_NAME", "env")
+	var e identConf
+	q := NewParser(SetIdent(JSON), SetEnvPrefix("APP"), SetSources(FileSource(path), EnvSource()))
+	if err := q.Resolve(&e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Mode != "test" || e.Name != "env" || e.Hosts[0].Addr != "h1" {
+		t.Fatalf("got %+v %+v", e.IdentCommon, e)
+	}
+}
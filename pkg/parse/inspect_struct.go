@@ -4,7 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
-	"strings"
+	"sort"
+	"strconv"
 )
 
 // InspectStruct 解析结构体
@@ -21,15 +22,28 @@ func (p *parser) InspectStruct(c any) error {
 	if t := rt.Elem().Kind(); t != reflect.Struct {
 		return errors.New("config variable must be a pointer to a struct")
 	}
-	// // struct
-	// fields, allFields, err := inspectField(rv.Elem(), nil, p.tagOpt)
-	// if err != nil {
-	// 	return err
-	// }
-	// p.fields = fields
-	// p.allFields = allFields
-	// p.setDefaults()
-	return p.setDefaultStruct(rv.Elem(), nil)
+	_, allFields, err := inspectField(rv.Elem(), nil, p.tagOpt)
+	if err != nil {
+		return err
+	}
+	for _, field := range allFields {
+		// the merge tags are checked even if no source sets the field
+		if field.tagValue.Merge != "" {
+			if _, err := p.mergeRule(field); err != nil {
+				return err
+			}
+		}
+	}
+	if err := p.setDefaults(allFields); err != nil {
+		return err
+	}
+	for _, field := range allFields {
+		if field.defaultValue.IsValid() {
+			// set by setDefaults
+			p.setOrigin(field, Origin{Source: SourceDefault, Key: field.tagValue.Default})
+		}
+	}
+	return nil
 }
 
 func (p *parser) setDefaultStruct(v reflect.Value, parent *parseField) error {
@@ -44,6 +58,7 @@ func (p *parser) setDefaultStruct(v reflect.Value, parent *parseField) error {
 }
 
 func inspectField(v reflect.Value, parentField *parseField, tagOpt *TagOption) (fields []*parseField, allFields []*parseField, err error) {
+	outer := outerIdents(v.Type(), tagOpt.IdentTag)
 	for i := 0; i < v.NumField(); i++ {
 		fieldValue := v.Field(i)
 		field := v.Type().Field(i)
@@ -65,8 +80,16 @@ func inspectField(v reflect.Value, parentField *parseField, tagOpt *TagOption) (
 			k = field.Type.Kind()
 		)
 
-		// If it is a pointer, it might be nil. Let's fill it with something.
-		if k == reflect.Ptr && fieldParse.value.IsNil() {
+		if !fieldParse.canSet {
+			// unexported field, can not be loaded.
+			fields = append(fields, fieldParse)
+			allFields = append(allFields, fieldParse)
+			continue
+		}
+
+		// If it is a pointer, it might be nil. Let's fill it with something,
+		// except the registered types such as *regexp.Regexp, whose zero value is not usable.
+		if k == reflect.Ptr && fieldParse.value.IsNil() && !isRegistered(t) {
 			fieldParse.value.Set(reflect.New(t.Elem()))
 		}
 		if k == reflect.Map && fieldParse.value.IsNil() {
@@ -74,7 +97,7 @@ func inspectField(v reflect.Value, parentField *parseField, tagOpt *TagOption) (
 		}
 
 		var anonymousFields []*parseField
-		if t.Implements(typeOfTextUnmarshaler) {
+		if isTextUnmarshaler(t) {
 			// TextUnmarshaler is a normal type, should not do more.
 		} else if k == reflect.Map {
 			fieldParse.isMap = true
@@ -91,6 +114,11 @@ func inspectField(v reflect.Value, parentField *parseField, tagOpt *TagOption) (
 				return nil, nil, err
 			}
 		}
+		if fieldParse.isInlined {
+			depth := len(fieldParse.fullIDParts)
+			fieldParse.subFields = dropShadowed(fieldParse.subFields, depth, outer)
+			anonymousFields = dropShadowed(anonymousFields, depth, outer)
+		}
 		fields = append(fields, fieldParse)
 		allFields = append(allFields, append(anonymousFields, fieldParse)...)
 		/**
@@ -110,40 +138,86 @@ func inspectField(v reflect.Value, parentField *parseField, tagOpt *TagOption) (
 	return
 }
 
-//
+// outerIdents returns the idents of the fields of the struct t which are not
+// inlined, they shadow the fields of the inlined structs.
+func outerIdents(t reflect.Type, identTag string) map[string]bool {
+	idents := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		if field := t.Field(i); !isInlined(field, identTag) {
+			idents[identFromField(field, identTag)] = true
+		}
+	}
+	return idents
+}
+
+// dropShadowed drops the fields of an inlined struct, and their children,
+// shadowed by the outer fields; depth is the length of the full ID of the
+// inlined struct.
+func dropShadowed(fields []*parseField, depth int, outer map[string]bool) []*parseField {
+	kept := fields[:0:0]
+	for _, field := range fields {
+		if len(field.fullIDParts) > depth && outer[field.fullIDParts[depth]] {
+			continue
+		}
+		kept = append(kept, field)
+	}
+	return kept
+}
+
+// parseFromField parses the tags of the field, the full ID is joined to the
+// one of the parent.
 func parseFromField(field reflect.StructField, parentField *parseField, tagOpt *TagOption) *parseField {
 	resultField := &parseField{}
-	ident := field.Tag.Get(tagOpt.IdentTag)
-	if len(ident) == 0 {
-		ident = strings.ToLower(field.Name)
-	}
+	ident := identFromField(field, tagOpt.IdentTag)
 	resultField.tagValue.Ident = ident
 
-	if parentField == nil {
-		resultField.fullIDParts = []string{ident}
-	} else {
+	resultField.isInlined = isInlined(field, tagOpt.IdentTag)
+	if parentField != nil {
 		resultField.fullIDParts = append(resultField.fullIDParts, parentField.fullIDParts...)
+	}
+	if !resultField.isInlined {
+		// the fields of an inlined struct have the full ID of its parent
 		resultField.fullIDParts = append(resultField.fullIDParts, ident)
 	}
 
 	resultField.tagValue.Describe = field.Tag.Get(tagOpt.DescTag)
 	resultField.tagValue.Option = field.Tag.Get(tagOpt.OptionTag)
 	resultField.tagValue.Valid = field.Tag.Get(tagOpt.ValidTag)
+	resultField.tagValue.Merge = field.Tag.Get(tagOpt.MergeTag)
+	resultField.tagValue.Layout = field.Tag.Get(tagOpt.LayoutTag)
+	resultField.tagValue.Unit = field.Tag.Get(tagOpt.UnitTag)
 	resultField.tagValue.Default, resultField.tagValue.DefaultSet = field.Tag.Lookup(tagOpt.DefaultTag)
 	return resultField
 }
 
 func (p *parser) setDefaults(allFields []*parseField) error {
+	// The literal defaults of nested options first, the default tags of
+	// their fields fill in the fields missing from the literal.
 	for _, opt := range allFields {
-		if !opt.tagValue.DefaultSet {
+		if !opt.isParent || !opt.tagValue.DefaultSet {
 			continue
 		}
-
-		if opt.isParent {
-			// Default values should not be set for nested options.
+		if !isLiteral(opt.tagValue.Default) {
+			// Only literal default values can be set for nested options.
 			return fmt.Errorf("default value specified for nested value '%v'",
 				opt.fullID())
 		}
+		if !opt.value.CanInterface() || !isZero(opt.value) {
+			continue
+		}
+		defaultValue, err := p.parseDefault(opt)
+		if err != nil {
+			return fmt.Errorf(
+				"error parsing default value for %v: %v", opt.fullID(), err)
+		}
+		opt.defaultValue = defaultValue
+		// the fields are inspected in place, a pointer is kept
+		reflect.Indirect(opt.value).Set(reflect.Indirect(defaultValue))
+	}
+	for _, opt := range allFields {
+		if !opt.tagValue.DefaultSet || opt.isParent {
+			continue
+		}
 		if !opt.value.CanInterface() {
 			continue // 不能修改值
 		}
@@ -153,24 +227,12 @@ func (p *parser) setDefaults(allFields []*parseField) error {
 			continue
 		}
 
-		opt.defaultValue = reflect.New(opt.value.Type()).Elem()
-		if isSlice(opt.value) {
-			if err := p.parseSlice(opt.defaultValue, opt.tagValue.Default); err != nil {
-				return fmt.Errorf(
-					"error parsing default value for %v: %v", opt.fullID(), err)
-			}
-		} else if isMap(opt.value) {
-			// TODO: set map default value
-			if err := p.parseMap(opt, opt.defaultValue, opt.tagValue.Default); err != nil {
-				return fmt.Errorf(
-					"error parsing default value for %v: %v", opt.fullID(), err)
-			}
-		} else {
-			if err := p.parseSimpleValue(opt.defaultValue, opt.tagValue.Default); err != nil {
-				return fmt.Errorf(
-					"error parsing default value for %v: %v", opt.fullID(), err)
-			}
+		defaultValue, err := p.parseDefault(opt)
+		if err != nil {
+			return fmt.Errorf(
+				"error parsing default value for %v: %v", opt.fullID(), err)
 		}
+		opt.defaultValue = defaultValue
 
 		if err := p.setValue(opt.value, opt.defaultValue, p.tagOpt); err != nil {
 			return fmt.Errorf("error setting default value for option "+
@@ -180,3 +242,99 @@ func (p *parser) setDefaults(allFields []*parseField) error {
 
 	return nil
 }
+
+// parseDefault parses the default tag into a new value of the field type.
+func (p *parser) parseDefault(opt *parseField) (reflect.Value, error) {
+	v := reflect.New(opt.value.Type()).Elem()
+	var err error
+	if isLiteral(opt.tagValue.Default) {
+		err = p.parseLiteral(v, opt)
+	} else if hasFormat(opt.tagValue) && !isMap(v) {
+		err = p.setValueByTag(v, opt.tagValue.Default, opt.tagValue)
+	} else if isSlice(v) {
+		err = p.parseSlice(v, opt.tagValue.Default)
+	} else if isMap(v) {
+		err = p.parseMap(opt, v, opt.tagValue.Default)
+	} else {
+		err = p.parseSimpleValue(v, opt.tagValue.Default)
+	}
+	return v, err
+}
+
+// walkFields calls fn for every exported field of the struct v without
+// modifying it, nested structs are walked after their parent, elements of
+// slices and maps of structs are walked with their index or key as part of
+// the full ID, e.g. l.0.name. The fields of inlined structs shadowed by an
+// outer field are skipped as by inspectField.
+func walkFields(v reflect.Value, parentField *parseField, tagOpt *TagOption, fn func(field *parseField) error) error {
+	var outer map[string]bool
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fieldParse := parseFromField(field, parentField, tagOpt)
+		fieldParse.value = v.Field(i)
+		fieldParse.canSet = fieldParse.value.CanSet()
+		fieldParse.isMap = isMap(fieldParse.value)
+		fieldParse.isSlice = isSlice(fieldParse.value)
+		elem := reflect.Indirect(fieldParse.value)
+		fieldParse.isParent = elem.Kind() == reflect.Struct && !isTextUnmarshaler(elem.Type())
+		if err := fn(fieldParse); err != nil {
+			return err
+		}
+		walk := fn
+		if fieldParse.isInlined {
+			if outer == nil {
+				outer = outerIdents(v.Type(), tagOpt.IdentTag)
+			}
+			depth := len(fieldParse.fullIDParts)
+			walk = func(field *parseField) error {
+				if len(field.fullIDParts) > depth && outer[field.fullIDParts[depth]] {
+					return nil
+				}
+				return fn(field)
+			}
+		}
+		if err := walkElems(fieldParse.value, fieldParse, tagOpt, walk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkElems walks the fields of the struct, slice or map v.
+func walkElems(v reflect.Value, parentField *parseField, tagOpt *TagOption, fn func(field *parseField) error) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if isTextUnmarshaler(v.Type()) {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		return walkFields(v, parentField, tagOpt, fn)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			elemField := &parseField{fullIDParts: append(append([]string(nil), parentField.fullIDParts...), strconv.Itoa(i))}
+			if err := walkElems(v.Index(i), elemField, tagOpt, fn); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+		})
+		for _, key := range keys {
+			elemField := &parseField{fullIDParts: append(append([]string(nil), parentField.fullIDParts...), fmt.Sprint(key))}
+			if err := walkElems(v.MapIndex(key), elemField, tagOpt, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package parse
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// cutLiteral returns the format and the literal of a default tag in the
+// literal form, e.g. `default:"json:[{\"name\":\"a\"}]"` or
+// `default:"yaml:{a: 1, b: 2}"`.
+func cutLiteral(s string) (fileFormat, string, bool) {
+	for _, name := range []string{JSON, YAML} {
+		if strings.HasPrefix(s, name+":") {
+			f, _ := formatByName(name)
+			return f, strings.TrimPrefix(s, name+":"), true
+		}
+	}
+	return fileFormat{}, "", false
+}
+
+func isLiteral(s string) bool {
+	_, _, ok := cutLiteral(s)
+	return ok
+}
+
+// parseLiteral sets v from the literal default of the field, keyed by the
+// idents of the fields as in a file. The fields missing from the literal get
+// their default tags.
+func (p *parser) parseLiteral(v reflect.Value, opt *parseField) error {
+	f, literal, _ := cutLiteral(opt.tagValue.Default)
+	var data interface{}
+	if f.name == JSON {
+		dec := json.NewDecoder(strings.NewReader(literal))
+		dec.UseNumber()
+		if err := dec.Decode(&data); err != nil {
+			return fmt.Errorf("invalid %v literal: %v", f.name, err)
+		}
+	} else {
+		if err := f.decoder([]byte(literal), &data); err != nil {
+			return fmt.Errorf("invalid %v literal: %v", f.name, err)
+		}
+		data = cleanUpYAML(data)
+	}
+	var err error
+	if hasFormat(opt.tagValue) {
+		err = p.fromGenericTag(v, data, opt.fullIDParts, opt.tagValue)
+	} else {
+		err = p.fromGeneric(v, data, opt.fullIDParts)
+	}
+	if err != nil {
+		return err
+	}
+	return p.setElemDefaults(v, opt)
+}
+
+// setElemDefaults sets the default tags of the zero fields of the structs in
+// v, the elements of slices and maps included.
+func (p *parser) setElemDefaults(v reflect.Value, parent *parseField) error {
+	if isTextUnmarshaler(v.Type()) {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			return p.setElemDefaults(v.Elem(), parent)
+		}
+	case reflect.Struct:
+		return p.setDefaultStruct(v, parent)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := p.setElemDefaults(v.Index(i), parent); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(iter.Value())
+			if err := p.setElemDefaults(elem, parent); err != nil {
+				return err
+			}
+			v.SetMapIndex(iter.Key(), elem)
+		}
+	}
+	return nil
+}
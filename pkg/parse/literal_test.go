@@ -0,0 +1,133 @@
+package parse
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type literalLogger struct {
+	Name   string   `json:"name" default:"default"`
+	Level  string   `json:"level" default:"info"`
+	Output []string `json:"output" default:"stdout"`
+}
+
+type literalRedis struct {
+	Host string `json:"host" default:"127.0.0.1"`
+	Port int    `json:"port" default:"6379"`
+	DB   int    `json:"db"`
+}
+
+type literalConf struct {
+	Weights  map[string]int           `json:"weights" default:"a=1,b=2"`
+	Loggers  map[string]literalLogger `json:"loggers" default:"app,server"`
+	Timeouts map[string]time.Duration `json:"timeouts" default:"read=5s,write=1m"`
+	Sizes    map[string]int64         `json:"sizes" unit:"bytes" default:"body=10MB"`
+	Output   []literalLogger          `json:"output" default:"json:[{\"name\":\"a\"},{\"name\":\"b\",\"level\":\"debug\"}]"`
+	Limits   map[string]int           `json:"limits" default:"yaml:{a: 1, b: 2}"`
+	Redis    literalRedis             `json:"redis" default:"yaml:{host: redis.local, db: 2}"`
+	Replica  *literalRedis            `json:"replica" default:"json:{\"port\":6380}"`
+	Days     []time.Time              `json:"days" layout:"DateOnly" default:"json:[\"2025-01-01\"]"`
+}
+
+func TestLiteralDefaults(t *testing.T) {
+	c := literalConf{}
+	p := NewParser(SetIdent(JSON))
+	if err := p.InspectStruct(&c); err != nil {
+		t.Fatal(err)
+	}
+	want := literalConf{
+		Weights: map[string]int{"a": 1, "b": 2},
+		Loggers: map[string]literalLogger{
+			"app":    {Name: "default", Level: "info", Output: []string{"stdout"}},
+			"server": {Name: "default", Level: "info", Output: []string{"stdout"}},
+		},
+		Timeouts: map[string]time.Duration{"read": 5 * time.Second, "write": time.Minute},
+		Sizes:    map[string]int64{"body": 10_000_000},
+		Output: []literalLogger{
+			{Name: "a", Level: "info", Output: []string{"stdout"}},
+			{Name: "b", Level: "debug", Output: []string{"stdout"}},
+		},
+		Limits:  map[string]int{"a": 1, "b": 2},
+		Redis:   literalRedis{Host: "redis.local", Port: 6379, DB: 2},
+		Replica: &literalRedis{Host: "127.0.0.1", Port: 6380},
+		Days:    []time.Time{time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("defaults:\n got %+v\nwant %+v", c, want)
+	}
+
+	// values set before InspectStruct are kept
+	c = literalConf{Redis: literalRedis{Host: "set"}, Weights: map[string]int{"c": 3}}
+	if err := NewParser(SetIdent(JSON)).InspectStruct(&c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Redis != (literalRedis{Host: "set", Port: 6379}) || !reflect.DeepEqual(c.Weights, map[string]int{"c": 3}) {
+		t.Errorf("set values: %+v %v", c.Redis, c.Weights)
+	}
+
+	schema, err := p.JSONSchema(&literalConf{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Properties map[string]struct {
+			Default interface{} `json:"default"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(schema, &doc); err != nil {
+		t.Fatal(err)
+	}
+	redis, _ := doc.Properties["redis"].Default.(map[string]interface{})
+	if redis["host"] != "redis.local" || redis["port"] != float64(6379) {
+		t.Errorf("schema default of redis: %v", doc.Properties["redis"].Default)
+	}
+}
+
+func TestLiteralDefaultsInvalid(t *testing.T) {
+	cases := map[string]interface{}{
+		"invalid json literal": &struct {
+			L []literalLogger `default:"json:[{"`
+		}{},
+		"m.a: failed to parse": &struct {
+			M map[string]int `default:"yaml:{a: x}"`
+		}{},
+		"literal form": &struct {
+			M map[string]literalLogger `default:"a=b"`
+		}{},
+		"nested value": &struct {
+			R literalRedis `default:"host"`
+		}{},
+		"value of key \"a\"": &struct {
+			M map[string]int `default:"a=x"`
+		}{},
+	}
+	for msg, c := range cases {
+		if err := NewParser(SetIdent(JSON)).InspectStruct(c); err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("%T: error should contain %q: %v", c, msg, err)
+		}
+	}
+}
+
+func TestMapValuesEnv(t *testing.T) {
+	t.Setenv("APP_WEIGHTS", "x=10,y=20")
+	c := literalConf{}
+	p := NewParser(SetIdent(JSON), SetEnvPrefix("APP"), SetArgs([]string{"--timeouts=idle=2h"}))
+	if err := p.InspectStruct(&c); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.LoadEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.LoadCmd(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(c.Weights, map[string]int{"a": 1, "b": 2, "x": 10, "y": 20}) {
+		t.Errorf("weights: %v", c.Weights)
+	}
+	if c.Timeouts["idle"] != 2*time.Hour || c.Timeouts["read"] != 5*time.Second {
+		t.Errorf("timeouts: %v", c.Timeouts)
+	}
+}
@@ -5,43 +5,104 @@ import (
 	"fmt"
 	"io/fs"
 	"io/ioutil"
+	"os"
 	"reflect"
 	"strconv"
 
 	"github.com/samber/lo"
 )
 
+// Load decodes content on top of the default tags of the source set by
+// InspectStruct, as Resolve with a ContentSource, and validates it. The format
+// is set by SetIdent, or sniffed from the content without SetIdent.
 func (p *parser) Load(content []byte) error {
-	if err := p.decoder(content, p.source); err != nil {
+	if _, err := p.sourceValue(); err != nil {
 		return err
 	}
-	return p.InspectStruct(p.source)
+	return p.resolve([]Source{ContentSource("", content)})
 }
 
+// ImportFile decodes the file in the format of its extension: .yaml, .yml,
+// .json or .toml. The format of the other files is set by SetIdent, or
+// sniffed from the content without SetIdent; "-" reads stdin.
 func (p *parser) ImportFile(fileName string) error {
-	content, err := ioutil.ReadFile(fileName)
+	var content []byte
+	var err error
+	if fileName == Stdin {
+		content, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		content, err = ioutil.ReadFile(fileName)
+	}
 	if err != nil {
 		return err
 	}
-	return p.decoder(content, p.source)
+	return p.loadContent(fileName, content)
 }
 
-func (p *parser) ExportFile(filePath string) error {
-	content, err := p.encoder(p.source)
+// loadContent decodes the content of the file fileName in the format of its
+// extension, see ImportFile.
+func (p *parser) loadContent(fileName string, content []byte) error {
+	f, err := p.detectFormat(fileName, content)
 	if err != nil {
 		return err
 	}
-	return ioutil.WriteFile(filePath, content, fs.FileMode(0600))
+	return p.decode(content, fileName, f)
 }
 
-func (p *parser) LoadEnv() error {
-	// TODO implement me
-	panic("implement me")
+// decode decodes the content of the file into the source, the keys are
+// routed by the idents of the fields whatever the format. In strict mode the
+// content is checked first. The slices and maps set by the file are merged
+// by their merge tag or SetSliceMerge.
+func (p *parser) decode(content []byte, fileName string, f fileFormat) error {
+	if p.strict {
+		if err := p.checkStrict(content, fileName, f); err != nil {
+			return err
+		}
+	}
+	keys, err := p.documentKeys(content, f)
+	if err != nil {
+		return err
+	}
+	snapshot, err := p.prepareMerge(keys)
+	if err != nil {
+		return err
+	}
+	doc, err := decodeGeneric(content, f)
+	if err != nil {
+		return err
+	}
+	rv, err := p.sourceValue()
+	if err != nil {
+		return err
+	}
+	if err := p.parseMapToStruct(reflect.ValueOf(doc), rv, nil); err != nil {
+		return err
+	}
+	if err := p.mergeSlices(snapshot, keys); err != nil {
+		return err
+	}
+	return p.setFileOrigins(keys, fileName)
 }
 
-func (p *parser) LoadCmd() error {
-	// TODO implement me
-	panic("implement me")
+// ExportFile encodes the source in the format of the extension of the file,
+// or set by SetIdent for the other extensions. The keys are the idents of the
+// fields whatever the format.
+func (p *parser) ExportFile(filePath string) error {
+	encoder := p.encoder
+	if f, ok := formatByExt(filePath); ok {
+		encoder = f.encoder
+	} else if p.decoder == nil {
+		return &UnknownFormatError{Path: filePath}
+	}
+	rv, err := p.sourceValue()
+	if err != nil {
+		return err
+	}
+	content, err := encoder(p.toGeneric(rv))
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filePath, content, fs.FileMode(0600))
 }
 
 func LoadStruct(c any, option *TagOption) error {
@@ -62,6 +123,9 @@ func LoadStruct(c any, option *TagOption) error {
 // parse reflect.Value set default value
 func parseValue(v reflect.Value, option *TagOption) error {
 
+	if v.Kind() != reflect.Pointer && isTextUnmarshaler(v.Type()) {
+		return parseSample(v, option) // time.Time, net.IP... are values, not structs or slices of options
+	}
 	setZeroType(v, option)
 	switch v.Type().Kind() {
 	case reflect.Struct:
@@ -175,7 +239,7 @@ func parseSample(v reflect.Value, option *TagOption) error {
 		if strV == "-" {
 			return nil
 		}
-		if err := newParserWithOption(option).parseSimpleValue(v, strV); err != nil {
+		if err := newParserWithOption(option).setValueByTag(v, strV, option.parseField.tagValue); err != nil {
 			return err
 		}
 	} else {
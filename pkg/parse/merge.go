@@ -0,0 +1,314 @@
+package parse
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// MergeMode is how a slice or a map loaded from a source is merged with the
+// value already in the field, e.g. loaded from a previous file or a base
+// config. Structs are merged field by field whatever the mode.
+type MergeMode int
+
+const (
+	MergeReplace MergeMode = iota // the source replaces the slice or the map
+	MergeAppend                   // the source is appended to the slice
+	MergeUnion                    // the elements of the source missing in the slice are appended
+	MergeByKey                    // the struct elements with the same key field are merged
+)
+
+func (m MergeMode) String() string {
+	switch m {
+	case MergeReplace:
+		return "replace"
+	case MergeAppend:
+		return "append"
+	case MergeUnion:
+		return "union"
+	case MergeByKey:
+		return "bykey"
+	}
+	return fmt.Sprintf("MergeMode(%d)", int(m))
+}
+
+// mergeRule is the merge tag of a field, e.g. `merge:"bykey=name"`.
+type mergeRule struct {
+	mode MergeMode
+	key  string // key field of MergeByKey
+}
+
+// parseMergeRule parses a merge tag: append, replace, union or bykey=name.
+func parseMergeRule(tag string) (mergeRule, error) {
+	name, key, hasKey := strings.Cut(strings.TrimSpace(tag), "=")
+	var rule mergeRule
+	switch name {
+	case "replace":
+		rule.mode = MergeReplace
+	case "append":
+		rule.mode = MergeAppend
+	case "union":
+		rule.mode = MergeUnion
+	case "bykey":
+		rule.mode = MergeByKey
+		rule.key = strings.TrimSpace(key)
+		if rule.key == "" {
+			return rule, fmt.Errorf("merge %q: missing key, e.g. bykey=name", tag)
+		}
+		return rule, nil
+	default:
+		return rule, fmt.Errorf("merge %q: unknown mode", tag)
+	}
+	if hasKey {
+		return rule, fmt.Errorf("merge %q: %v takes no key", tag, name)
+	}
+	return rule, nil
+}
+
+// mergeRule returns the merge rule of the field: its merge tag, else
+// SetSliceMerge for slices. Maps are merged key by key unless replaced.
+func (p *parser) mergeRule(field *parseField) (mergeRule, error) {
+	t := field.value.Type()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if field.tagValue.Merge == "" {
+		if t.Kind() == reflect.Map {
+			return mergeRule{mode: MergeUnion}, nil
+		}
+		return mergeRule{mode: p.sliceMerge}, nil
+	}
+	rule, err := parseMergeRule(field.tagValue.Merge)
+	if err != nil {
+		return rule, fmt.Errorf("field %v: %w", field.fullID(), err)
+	}
+	switch {
+	case t.Kind() == reflect.Map && rule.mode == MergeByKey:
+		return rule, fmt.Errorf("field %v: merge bykey needs a slice of structs", field.fullID())
+	case t.Kind() == reflect.Map:
+		if rule.mode != MergeReplace {
+			rule.mode = MergeUnion // merged key by key
+		}
+	case t.Kind() != reflect.Slice:
+		return rule, fmt.Errorf("field %v: merge needs a slice or a map", field.fullID())
+	case rule.mode == MergeByKey:
+		if _, ok := keyField(t.Elem(), rule.key, p.tagOpt.IdentTag); !ok {
+			return rule, fmt.Errorf("field %v: merge key %q not found in %v", field.fullID(), rule.key, t.Elem())
+		}
+	}
+	return rule, nil
+}
+
+// keyField returns the index of the field of the struct t (or *t) named key
+// by the ident tag or by its name.
+func keyField(t reflect.Type, key, identTag string) (int, bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return 0, false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.IsExported() && (identFromField(field, identTag) == key || strings.EqualFold(field.Name, key)) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// sliceValue returns the slice or the map of the field through its pointers,
+// false if the field is neither.
+func sliceValue(v reflect.Value) (reflect.Value, bool) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Value{}, false
+		}
+		v = v.Elem()
+	}
+	return v, v.Kind() == reflect.Slice || v.Kind() == reflect.Map
+}
+
+// copySlice copies the elements of v, decoders reuse the backing array.
+func copySlice(v reflect.Value) reflect.Value {
+	c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+	reflect.Copy(c, v)
+	return c
+}
+
+// prepareMerge prepares the source for a file setting keys: the replaced
+// maps set by the file are emptied, as decoders merge maps key by key, and
+// the slices set by the file are emptied after copying the merged ones,
+// keyed by the full ID of the fields.
+func (p *parser) prepareMerge(keys map[string]docPosition) (map[string]reflect.Value, error) {
+	rv, err := p.sourceValue()
+	if err != nil {
+		return nil, err
+	}
+	_, allFields, err := inspectField(rv, nil, p.tagOpt)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := make(map[string]reflect.Value)
+	for _, field := range allFields {
+		if !field.canSet {
+			continue
+		}
+		v, ok := sliceValue(field.value)
+		if _, isSet := keys[field.fullID()]; !isSet || !ok && field.tagValue.Merge == "" {
+			continue
+		}
+		rule, err := p.mergeRule(field)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case v.Kind() == reflect.Map && rule.mode == MergeReplace:
+			v.Set(reflect.MakeMap(v.Type()))
+		case v.Kind() == reflect.Slice:
+			if rule.mode != MergeReplace && v.Len() > 0 {
+				snapshot[field.fullID()] = copySlice(v)
+			}
+			// decoders decode into the elements of the backing array
+			v.Set(reflect.Zero(v.Type()))
+		}
+	}
+	return snapshot, nil
+}
+
+// mergeSlices merges the snapshot into the slices set by a file.
+func (p *parser) mergeSlices(snapshot map[string]reflect.Value, keys map[string]docPosition) error {
+	if len(snapshot) == 0 {
+		return nil
+	}
+	rv, err := p.sourceValue()
+	if err != nil {
+		return err
+	}
+	_, allFields, err := inspectField(rv, nil, p.tagOpt)
+	if err != nil {
+		return err
+	}
+	for _, field := range allFields {
+		old, ok := snapshot[field.fullID()]
+		if !ok {
+			continue
+		}
+		if v, ok := sliceValue(field.value); ok {
+			if err := p.mergeSlice(field, v, old, keys); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// mergeSlice merges old, the slice before the source, into v. keys are the
+// keys set by a file, nil for env and flags.
+func (p *parser) mergeSlice(field *parseField, v, old reflect.Value, keys map[string]docPosition) error {
+	rule, err := p.mergeRule(field)
+	if err != nil {
+		return err
+	}
+	switch rule.mode {
+	case MergeAppend:
+		v.Set(reflect.AppendSlice(old, v))
+	case MergeUnion:
+		merged := old
+		for i := 0; i < v.Len(); i++ {
+			if indexOf(merged, v.Index(i)) < 0 {
+				merged = reflect.Append(merged, v.Index(i))
+			}
+		}
+		v.Set(merged)
+	case MergeByKey:
+		i, _ := keyField(v.Type().Elem(), rule.key, p.tagOpt.IdentTag)
+		merged := old
+		for j := 0; j < v.Len(); j++ {
+			elem := v.Index(j)
+			k := elemKey(elem, i)
+			if !k.IsValid() {
+				merged = reflect.Append(merged, elem)
+				continue
+			}
+			at := -1
+			for n := 0; n < merged.Len() && at < 0; n++ {
+				if mk := elemKey(merged.Index(n), i); mk.IsValid() && reflect.DeepEqual(mk.Interface(), k.Interface()) {
+					at = n
+				}
+			}
+			if at < 0 {
+				merged = reflect.Append(merged, elem)
+				continue
+			}
+			prefix := field.fullID() + "." + fmt.Sprint(j)
+			if err := p.mergeElem(merged.Index(at), elem, prefix, keys); err != nil {
+				return err
+			}
+		}
+		v.Set(merged)
+	}
+	return nil
+}
+
+// indexOf returns the index of the first element of s deeply equal to e.
+func indexOf(s, e reflect.Value) int {
+	for i := 0; i < s.Len(); i++ {
+		if reflect.DeepEqual(s.Index(i).Interface(), e.Interface()) {
+			return i
+		}
+	}
+	return -1
+}
+
+// elemKey returns the key field i of a struct element, invalid for nil.
+func elemKey(elem reflect.Value, i int) reflect.Value {
+	for elem.Kind() == reflect.Ptr {
+		if elem.IsNil() {
+			return reflect.Value{}
+		}
+		elem = elem.Elem()
+	}
+	return elem.Field(i)
+}
+
+// mergeElem sets the fields of src set by the file, under prefix, into dst;
+// without keys src replaces dst.
+func (p *parser) mergeElem(dst, src reflect.Value, prefix string, keys map[string]docPosition) error {
+	if keys == nil {
+		dst.Set(src)
+		return nil
+	}
+	for src.Kind() == reflect.Ptr {
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		src, dst = src.Elem(), dst.Elem()
+	}
+	_, srcFields, err := inspectField(src, nil, p.tagOpt)
+	if err != nil {
+		return err
+	}
+	_, dstFields, err := inspectField(dst, nil, p.tagOpt)
+	if err != nil {
+		return err
+	}
+	dstByID := make(map[string]*parseField, len(dstFields))
+	for _, field := range dstFields {
+		dstByID[field.fullID()] = field
+	}
+	for _, field := range srcFields {
+		if field.isParent || !field.canSet {
+			continue
+		}
+		if _, ok := keys[prefix+"."+field.fullID()]; !ok {
+			continue
+		}
+		dstField, ok := dstByID[field.fullID()]
+		if !ok {
+			continue
+		}
+		dstField.value.Set(field.value)
+	}
+	return nil
+}
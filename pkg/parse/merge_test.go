@@ -0,0 +1,143 @@
+package parse
+
+import (
+	"reflect"
+	"testing"
+)
+
+type mergeConf struct {
+	Hosts []string          `yaml:"hosts" default:"a,b"`
+	Ports []int             `yaml:"ports"`
+	Tags  map[string]string `yaml:"tags"`
+	Redis *envRedis         `yaml:"redis"`
+}
+
+func TestSliceMerge(t *testing.T) {
+	base := writeFile(t, "base.yaml", "ports: [1]\ntags:\n  env: prod\n  zone: a\nredis:\n  host: redis\n")
+	patch := writeFile(t, "patch.yaml", "hosts: [c]\ntags:\n  zone: b\nredis:\n  port: 7\n")
+	t.Setenv("APP_PORTS", "2,3")
+
+	tests := []struct {
+		mode  MergeMode
+		hosts []string
+		ports []int
+	}{
+		{MergeReplace, []string{"c"}, []int{2, 3}},
+		{MergeAppend, []string{"a", "b", "c"}, []int{1, 2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode.String(), func(t *testing.T) {
+			var c mergeConf
+			p := NewParser(SetIdent(YAML), SetEnvPrefix("APP"), SetSliceMerge(tt.mode),
+				SetSources(DefaultSource(), FileSource(base), FileSource(patch), EnvSource()))
+			if err := p.Resolve(&c); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(c.Hosts, tt.hosts) || !reflect.DeepEqual(c.Ports, tt.ports) {
+				t.Fatalf("hosts %v ports %v, want %v %v", c.Hosts, c.Ports, tt.hosts, tt.ports)
+			}
+			// structs and maps are merged whatever the mode
+			if want := map[string]string{"env": "prod", "zone": "b"}; !reflect.DeepEqual(c.Tags, want) {
+				t.Fatalf("tags %v, want %v", c.Tags, want)
+			}
+			if c.Redis.Host != "redis" || c.Redis.Port != 7 {
+				t.Fatalf("redis %+v", *c.Redis)
+			}
+		})
+	}
+}
+
+type mergeTagConf struct {
+	WhiteIP []string          `yaml:"white_ip" merge:"union"`
+	Hosts   []string          `yaml:"hosts" merge:"append"`
+	Tags    map[string]string `yaml:"tags" merge:"replace"`
+	Labels  map[string]string `yaml:"labels"`
+	Loggers []mergeLogger     `yaml:"loggers" merge:"bykey=name"`
+}
+
+type mergeLogger struct {
+	Name   string   `yaml:"name"`
+	Level  string   `yaml:"level"`
+	Output []string `yaml:"output"`
+}
+
+func TestMergeTag(t *testing.T) {
+	base := writeFile(t, "base.yaml", `white_ip: [10.0.0.1, 10.0.0.2]
+hosts: [a]
+tags: {env: prod}
+labels: {env: prod}
+loggers:
+  - name: app
+    level: info
+    output: [stdio]
+  - name: access
+    level: warn
+`)
+	patch := writeFile(t, "patch.yaml", `white_ip: [10.0.0.2, 10.0.0.3]
+hosts: [b]
+tags: {zone: b}
+labels: {zone: b}
+loggers:
+  - name: app
+    level: debug
+  - name: audit
+    level: error
+`)
+	t.Setenv("APP_WHITE_IP", "10.0.0.3,10.0.0.4")
+	t.Setenv("APP_HOSTS", "c")
+	t.Setenv("APP_LABELS", "team")
+
+	var c mergeTagConf
+	p := NewParser(SetIdent(YAML), SetEnvPrefix("APP"), SetArgs([]string{"--hosts=d"}),
+		SetSources(FileSource(base), FileSource(patch), EnvSource(), CmdSource()))
+	if err := p.Resolve(&c); err != nil {
+		t.Fatal(err)
+	}
+	want := mergeTagConf{
+		WhiteIP: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4"},
+		Hosts:   []string{"a", "b", "c", "d"},
+		Tags:    map[string]string{"zone": "b"},
+		Labels:  map[string]string{"env": "prod", "zone": "b", "team": ""},
+		Loggers: []mergeLogger{
+			{Name: "app", Level: "debug", Output: []string{"stdio"}},
+			{Name: "access", Level: "warn"},
+			{Name: "audit", Level: "error"},
+		},
+	}
+	if !reflect.DeepEqual(c, want) {
+		t.Fatalf("got  %+v\nwant %+v", c, want)
+	}
+}
+
+func TestMergeTagInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		conf any
+	}{
+		{"mode", &struct {
+			Hosts []string `yaml:"hosts" merge:"concat"`
+		}{}},
+		{"key", &struct {
+			Loggers []mergeLogger `yaml:"loggers" merge:"bykey=id"`
+		}{}},
+		{"map bykey", &struct {
+			Tags map[string]string `yaml:"tags" merge:"bykey=name"`
+		}{}},
+		{"scalar", &struct {
+			Hosts string `yaml:"hosts" merge:"append"`
+		}{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, "conf.yaml", "hosts: a\nloggers: [{name: a}]\ntags: {a: b}\n")
+			p := NewParser(SetIdent(YAML), SetSources(FileSource(path)))
+			if err := p.Resolve(tt.conf); err == nil {
+				t.Fatal("invalid merge tag accepted")
+			}
+			// without a file setting the field
+			if err := NewParser(SetIdent(YAML)).InspectStruct(tt.conf); err == nil {
+				t.Fatal("invalid merge tag accepted by InspectStruct")
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package parse
+
+import (
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+type netConf struct {
+	Listen   netip.AddrPort `yaml:"listen" default:"0.0.0.0:8080"`
+	Gateway  netip.Addr     `yaml:"gateway" default:"10.0.0.1"`
+	Subnet   netip.Prefix   `yaml:"subnet" default:"10.0.0.0/8"`
+	WhiteIP  []net.IP       `yaml:"white_ip" default:"127.0.0.1,::1" valid:"ip"`
+	Trusted  *net.IPNet     `yaml:"trusted" default:"192.168.0.0/16"`
+	Upstream url.URL        `yaml:"upstream" default:"https://api.local:8443/v1?q=1"`
+	Proxy    *url.URL       `yaml:"proxy"`
+	Pattern  *regexp.Regexp `yaml:"pattern" default:"^/api/v[0-9]+/"`
+}
+
+func TestNetTypesDefaults(t *testing.T) {
+	c := netConf{}
+	p := NewParser()
+	if err := p.InspectStruct(&c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Listen != netip.MustParseAddrPort("0.0.0.0:8080") || c.Gateway != netip.MustParseAddr("10.0.0.1") ||
+		c.Subnet != netip.MustParsePrefix("10.0.0.0/8") {
+		t.Errorf("netip: %v %v %v", c.Listen, c.Gateway, c.Subnet)
+	}
+	if len(c.WhiteIP) != 2 || !c.WhiteIP[0].Equal(net.IPv4(127, 0, 0, 1)) || !c.WhiteIP[1].Equal(net.IPv6loopback) {
+		t.Errorf("white ip: %v", c.WhiteIP)
+	}
+	if c.Trusted == nil || c.Trusted.String() != "192.168.0.0/16" {
+		t.Errorf("trusted: %v", c.Trusted)
+	}
+	if c.Upstream.Host != "api.local:8443" || c.Upstream.Path != "/v1" || c.Upstream.RawQuery != "q=1" {
+		t.Errorf("upstream: %+v", c.Upstream)
+	}
+	if c.Proxy != nil {
+		t.Errorf("proxy without default should stay nil: %v", c.Proxy)
+	}
+	if c.Pattern == nil || !c.Pattern.MatchString("/api/v2/users") {
+		t.Errorf("pattern: %v", c.Pattern)
+	}
+	if err := p.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	for _, invalid := range []interface{}{
+		&struct {
+			IP net.IP `default:"300.0.0.1"`
+		}{},
+		&struct {
+			Net *net.IPNet `default:"10.0.0.1"`
+		}{},
+		&struct {
+			Re *regexp.Regexp `default:"(a"`
+		}{},
+		&struct {
+			Addr netip.AddrPort `default:"10.0.0.1"`
+		}{},
+	} {
+		if err := NewParser().InspectStruct(invalid); err == nil {
+			t.Errorf("%T: invalid default should fail", invalid)
+		}
+	}
+}
+
+func TestNetTypesEnvAndCmd(t *testing.T) {
+	t.Setenv("APP_WHITE_IP", "10.1.1.1,10.1.1.2")
+	t.Setenv("APP_PROXY", "http://proxy.local:3128")
+	c := netConf{}
+	p := NewParser(SetEnvPrefix("APP"), SetArgs([]string{"--trusted=172.16.0.0/12", "--pattern=^x$"}))
+	if err := p.InspectStruct(&c); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.LoadEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.LoadCmd(); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.WhiteIP) != 2 || c.WhiteIP[1].String() != "10.1.1.2" {
+		t.Errorf("white ip: %v", c.WhiteIP)
+	}
+	if c.Proxy == nil || c.Proxy.Host != "proxy.local:3128" {
+		t.Errorf("proxy: %v", c.Proxy)
+	}
+	if c.Trusted.String() != "172.16.0.0/12" || c.Pattern.String() != "^x$" {
+		t.Errorf("trusted %v, pattern %v", c.Trusted, c.Pattern)
+	}
+}
+
+func TestNetTypesFiles(t *testing.T) {
+	path := writeFile(t, "conf.yaml", `listen: "[::1]:9090"
+white_ip: [192.168.1.1]
+trusted: 10.10.0.0/16
+upstream: http://backend/
+pattern: ^ok$
+`)
+	c := netConf{}
+	p := NewParser(SetStrict(true))
+	if err := p.InspectStruct(&c); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.ImportFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if c.Listen.Port() != 9090 || c.WhiteIP[0].String() != "192.168.1.1" || c.Trusted.String() != "10.10.0.0/16" ||
+		c.Upstream.String() != "http://backend/" || c.Pattern.String() != "^ok$" {
+		t.Errorf("file: %+v", c)
+	}
+
+	out := filepath.Join(t.TempDir(), "conf.json")
+	if err := p.ExportFile(out); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{`"listen": "[::1]:9090"`, `"192.168.1.1"`, `"trusted": "10.10.0.0/16"`, `"upstream": "http://backend/"`, `"pattern": "^ok$"`, `"subnet": "10.0.0.0/8"`} {
+		if !strings.Contains(string(content), s) {
+			t.Errorf("export should contain %v:\n%s", s, content)
+		}
+	}
+	loaded := netConf{}
+	p = NewParser()
+	if err := p.InspectStruct(&loaded); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.ImportFile(out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(p.(*parser).toGeneric(reflect.ValueOf(loaded)), p.(*parser).toGeneric(reflect.ValueOf(c))) {
+		t.Errorf("round trip:\n got %+v\nwant %+v", loaded, c)
+	}
+
+	path = writeFile(t, "invalid.yaml", "white_ip: [not-an-ip]\n")
+	p = NewParser()
+	if err := p.InspectStruct(&netConf{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.ImportFile(path); err == nil || !strings.Contains(err.Error(), "white_ip.0") {
+		t.Errorf("an invalid ip should fail: %v", err)
+	}
+}
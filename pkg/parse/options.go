@@ -1,12 +1,16 @@
 package parse
 
 import (
+	"io"
 	"reflect"
 	"strings"
+	"time"
 )
 
 type SetOpt func(p *parser)
 
+// SetIdent sets the tag naming the keys in all the formats and env, e.g. json,
+// and the format of the files without a known extension.
 func SetIdent(ident string) SetOpt {
 	return func(p *parser) {
 		p.tagOpt.IdentTag = ident
@@ -24,6 +28,79 @@ func SetIdent(ident string) SetOpt {
 	}
 }
 
+// SetEnvPrefix sets the prefix of env names, e.g. APP for APP_REDIS_PORT.
+func SetEnvPrefix(prefix string) SetOpt {
+	return func(p *parser) {
+		p.envPrefix = prefix
+	}
+}
+
+// SetEnvSeparator sets the separator between the prefix and the idents of
+// env names, default "_".
+func SetEnvSeparator(sep string) SetOpt {
+	return func(p *parser) {
+		p.envSeparator = sep
+	}
+}
+
+// SetArgs sets the command-line args parsed by LoadCmd instead of os.Args[1:].
+func SetArgs(args []string) SetOpt {
+	return func(p *parser) {
+		p.args = args
+	}
+}
+
+// SetOutput sets the writer of the usage and errors printed by LoadCmd
+// instead of os.Stderr.
+func SetOutput(w io.Writer) SetOpt {
+	return func(p *parser) {
+		p.output = w
+	}
+}
+
+// SetSources sets the sources applied by Resolve, see Resolve for the priority.
+func SetSources(sources ...Source) SetOpt {
+	return func(p *parser) {
+		p.sources = append(p.sources, sources...)
+	}
+}
+
+// SetStrict checks files against the source struct before decoding, unknown
+// keys and type mismatches are rejected; the required keys missing from all
+// the sources are rejected by Resolve and Load.
+func SetStrict(strict bool) SetOpt {
+	return func(p *parser) {
+		p.strict = strict
+	}
+}
+
+// SetDebounce sets the delay of Watch reloads after the last write of the
+// file, default 100ms.
+func SetDebounce(delay time.Duration) SetOpt {
+	return func(p *parser) {
+		p.debounce = delay
+	}
+}
+
+// SetSliceMerge sets how the slices loaded from files, env and flags are
+// merged with the slices already loaded, MergeReplace by default. The merge
+// tag of a field overrides it, MergeByKey is only set by the tag.
+func SetSliceMerge(mode MergeMode) SetOpt {
+	return func(p *parser) {
+		p.sliceMerge = mode
+	}
+}
+
+// SetOverlay loads the sources of Resolve and Load on top of the values
+// already in the struct without applying the default tags, e.g. a sparse
+// config loaded on a copy of a resolved one, where a zero value is a value
+// and not a missing one.
+func SetOverlay(overlay bool) SetOpt {
+	return func(p *parser) {
+		p.overlay = overlay
+	}
+}
+
 func SetValidTag(tag string) SetOpt {
 	return func(p *parser) {
 		p.tagOpt.ValidTag = tag
@@ -36,6 +113,30 @@ func SetDescTag(tag string) SetOpt {
 	}
 }
 
+func SetOptionTag(tag string) SetOpt {
+	return func(p *parser) {
+		p.tagOpt.OptionTag = tag
+	}
+}
+
+func SetMergeTag(tag string) SetOpt {
+	return func(p *parser) {
+		p.tagOpt.MergeTag = tag
+	}
+}
+
+func SetLayoutTag(tag string) SetOpt {
+	return func(p *parser) {
+		p.tagOpt.LayoutTag = tag
+	}
+}
+
+func SetUnitTag(tag string) SetOpt {
+	return func(p *parser) {
+		p.tagOpt.UnitTag = tag
+	}
+}
+
 func SetDefaultTag(tag string) SetOpt {
 	return func(p *parser) {
 		p.tagOpt.DefaultTag = tag
@@ -47,10 +148,13 @@ type (
 	TagOption struct {
 		IdentTag   string // yaml,json,toml,id等
 		DefaultTag string // 默认值，会被更高优先级覆盖
-		OptionTag  string // 选项,
+		OptionTag  string // 选项，只能选择其中某些值，** 匹配任意字符: stdio,file://**
 		DescTag    string // 描述，html显示;
 		// Option     string // 选项，只能选择其中某些值 html显示 Usage: oneof=red green \n oneof=5 7 9
 		ValidTag   string // 验证 github.com/go-playground/validator/v10
+		MergeTag   string // 合并 slice,map: append,replace,union,bykey=name
+		LayoutTag  string // time.Time 格式: 2006-01-02, DateOnly, 默认 RFC3339
+		UnitTag    string // 单位: bytes, int 可写 10MB, 1.5GiB
 		parseField *parseField
 	}
 	// TagValue 值
@@ -61,6 +165,9 @@ type (
 		Option     string `json:"option"`
 		Describe   string `json:"describe"`
 		Valid      string `json:"valid"`
+		Merge      string `json:"merge"`
+		Layout     string `json:"layout"`
+		Unit       string `json:"unit"`
 	}
 )
 
@@ -69,7 +176,11 @@ func NewDefaultTagOpt() *TagOption {
 		IdentTag:   YAML,
 		DefaultTag: DefaultTag,
 		DescTag:    DescTag,
+		OptionTag:  OptionTag,
 		ValidTag:   ValidTag,
+		MergeTag:   MergeTag,
+		LayoutTag:  LayoutTag,
+		UnitTag:    UnitTag,
 	}
 }
 
@@ -82,6 +193,7 @@ type parseField struct {
 	isParent     bool          // is nested and has children
 	isMap        bool          // is a map type
 	isSlice      bool          // is a slice
+	isInlined    bool          // is an embedded struct whose fields are keyed as the fields of its parent
 	canSet       bool          //
 	tagValue     TagValue      // struct.tag
 }
@@ -98,18 +210,20 @@ func (t *TagOption) clone() *TagOption {
 		OptionTag:  t.OptionTag,
 		DescTag:    t.DescTag,
 		ValidTag:   t.ValidTag,
+		MergeTag:   t.MergeTag,
+		LayoutTag:  t.LayoutTag,
+		UnitTag:    t.UnitTag,
 	}
 }
 func (t *TagOption) parseFromField(field reflect.StructField) *TagOption {
 	resultField := &parseField{}
-	ident := field.Tag.Get(t.IdentTag)
-	if len(ident) == 0 {
-		ident = strings.ToLower(field.Name)
-	}
-	resultField.tagValue.Ident = ident
+	resultField.tagValue.Ident = identFromField(field, t.IdentTag)
 	resultField.tagValue.Describe = field.Tag.Get(t.DescTag)
 	resultField.tagValue.Option = field.Tag.Get(t.OptionTag)
 	resultField.tagValue.Valid = field.Tag.Get(t.ValidTag)
+	resultField.tagValue.Merge = field.Tag.Get(t.MergeTag)
+	resultField.tagValue.Layout = field.Tag.Get(t.LayoutTag)
+	resultField.tagValue.Unit = field.Tag.Get(t.UnitTag)
 	resultField.tagValue.Default, resultField.tagValue.DefaultSet = field.Tag.Lookup(t.DefaultTag)
 	// return resultField
 	res := t.clone()
@@ -117,6 +231,16 @@ func (t *TagOption) parseFromField(field reflect.StructField) *TagOption {
 	return res
 }
 
+// identFromField returns the key name of the field, options of the tag such
+// as `json:"name,omitempty"` are dropped.
+func identFromField(field reflect.StructField, identTag string) string {
+	ident, _, _ := strings.Cut(field.Tag.Get(identTag), ",")
+	if len(ident) == 0 {
+		ident = strings.ToLower(field.Name)
+	}
+	return ident
+}
+
 func (t *TagOption) getDefault() []string {
 	if t == nil || t.parseField == nil {
 		return []string{}
@@ -0,0 +1,127 @@
+package parse
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Origin is the source that last set a field.
+type Origin struct {
+	Source SourceKind
+	Path   string // file path of SourceFile, empty for Load
+	Line   int    // line in the file, 0 if unknown
+	Column int    // column in the file, 0 if unknown
+	Key    string // default tag of SourceDefault, env name of SourceEnv, flag name of SourceCmd
+}
+
+// String returns the location of the origin, e.g. file:conf.yaml:12.
+func (o Origin) String() string {
+	switch o.Source {
+	case SourceFile:
+		if o.Line > 0 {
+			return o.Source.String() + ":" + o.Path + ":" + strconv.Itoa(o.Line)
+		}
+		return o.Source.String() + ":" + o.Path
+	case SourceEnv, SourceCmd:
+		return o.Source.String() + ":" + o.Key
+	}
+	return o.Source.String() + ":" + strconv.Quote(o.Key)
+}
+
+// Explain returns the origin of the field by its full ID, e.g. redis.port.
+func (p *parser) Explain(id string) (Origin, bool) {
+	origin, ok := p.origins[id]
+	return origin, ok
+}
+
+// ExplainAll writes the origins of all the fields set as a table.
+func (p *parser) ExplainAll(w io.Writer) error {
+	ids := make([]string, 0, len(p.origins))
+	for id := range p.origins {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "FIELD\tSOURCE\tLOCATION")
+	for _, id := range ids {
+		origin := p.origins[id]
+		location := origin.Key
+		if origin.Source == SourceFile {
+			location = origin.Path
+			if origin.Line > 0 {
+				location = fmt.Sprintf("%v:%d:%d", origin.Path, origin.Line, origin.Column)
+			}
+		}
+		fmt.Fprintf(tw, "%v\t%v\t%v\n", id, origin.Source, location)
+	}
+	return tw.Flush()
+}
+
+// setOrigin records the origin of the field.
+func (p *parser) setOrigin(field *parseField, origin Origin) {
+	if p.origins == nil {
+		p.origins = make(map[string]Origin)
+	}
+	p.origins[field.fullID()] = origin
+}
+
+// documentKeys returns the keys set by the content of a file, e.g.
+// redis.port, with their positions if the format is indexed.
+func (p *parser) documentKeys(content []byte, f fileFormat) (map[string]docPosition, error) {
+	index, err := documentIndex(content, f.name)
+	if err == nil {
+		return index, nil
+	}
+	// The decoder accepted the content, fall back to the keys without
+	// positions.
+	var doc map[string]interface{}
+	if err := f.decoder(content, &doc); err != nil {
+		return nil, err
+	}
+	index = make(map[string]docPosition)
+	indexKeys(cleanUpYAML(doc), nil, index)
+	return index, nil
+}
+
+// setFileOrigins records the fields set by the keys of a file.
+func (p *parser) setFileOrigins(keys map[string]docPosition, path string) error {
+	rv, err := p.sourceValue()
+	if err != nil {
+		return err
+	}
+	_, allFields, err := inspectField(rv, nil, p.tagOpt)
+	if err != nil {
+		return err
+	}
+	for _, field := range allFields {
+		if field.isParent {
+			continue
+		}
+		if pos, ok := keys[field.fullID()]; ok {
+			p.setOrigin(field, Origin{Source: SourceFile, Path: path, Line: pos.Line, Column: pos.Column})
+		}
+	}
+	return nil
+}
+
+// indexKeys indexes the keys of a decoded document without positions.
+func indexKeys(v interface{}, parts []string, index map[string]docPosition) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			childParts := append(append([]string(nil), parts...), key)
+			index[strings.Join(childParts, ".")] = docPosition{}
+			indexKeys(child, childParts, index)
+		}
+	case []interface{}:
+		for i, child := range v {
+			childParts := append(append([]string(nil), parts...), strconv.Itoa(i))
+			index[strings.Join(childParts, ".")] = docPosition{}
+			indexKeys(child, childParts, index)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package parse
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	file := writeFile(t, "conf.yaml", "name: base\n# comment\nredis:\n  host: redis\n")
+	t.Setenv("APP_LEVEL", "error")
+
+	c := resolveConf{}
+	p := NewParser(SetIdent(YAML), SetEnvPrefix("APP"),
+		SetArgs([]string{"--redis.port=4"}),
+		SetSources(DefaultSource(), FileSource(file), EnvSource(), CmdSource()),
+	)
+	if err := p.Resolve(&c); err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]Origin{
+		"name":       {Source: SourceFile, Path: file, Line: 1, Column: 1},
+		"mode":       {Source: SourceDefault, Key: "dev"},
+		"level":      {Source: SourceEnv, Key: "APP_LEVEL"},
+		"redis.host": {Source: SourceFile, Path: file, Line: 4, Column: 3},
+		"redis.port": {Source: SourceCmd, Key: "--redis.port"},
+	}
+	for id, want := range cases {
+		if got, ok := p.Explain(id); !ok || got != want {
+			t.Errorf("%v: got %+v, want %+v", id, got, want)
+		}
+	}
+	if _, ok := p.Explain("redis"); ok {
+		t.Error("nested struct should not have an origin")
+	}
+
+	buf := &bytes.Buffer{}
+	if err := p.ExplainAll(buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 6 || strings.Join(strings.Fields(lines[5]), " ") != "redis.port flag --redis.port" {
+		t.Errorf("table:\n%v", buf.String())
+	}
+}
+
+func TestDocumentIndexTOML(t *testing.T) {
+	content := `# comment
+name = "app"
+hosts = [
+  "a", # [
+  "b",
+]
+desc = """
+key = 1
+"""
+
+[redis]
+"port" = 6379
+
+[[l]]
+name = "a"
+[[l]]
+name = "b"
+`
+	index, err := documentIndex([]byte(content), TOML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]int{
+		"name":       2,
+		"hosts":      3,
+		"desc":       7,
+		"redis":      11,
+		"redis.port": 12,
+		"l":          16,
+		"l.0.name":   15,
+		"l.1.name":   17,
+	}
+	for path, line := range cases {
+		if pos := index[path]; pos.Line != line {
+			t.Errorf("%v: got line %v, want %v", path, pos.Line, line)
+		}
+	}
+	if _, ok := index["key"]; ok {
+		t.Error("multi-line string should be skipped")
+	}
+}
+
+func TestExplainReuse(t *testing.T) {
+	type explainConf struct {
+		Name string `yaml:"name"`
+		Mode string `yaml:"mode" default:"dev"`
+	}
+	file := writeFile(t, "conf.yaml", "name: base\n")
+	c := explainConf{}
+	p := NewParser(SetIdent(YAML), SetSources(FileSource(file)))
+	if err := p.Resolve(&c); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := p.Explain("name"); !ok {
+		t.Fatal("name should be set by the file")
+	}
+	// a reused parser explains the last sources only
+	if err := p.Load([]byte("mode: prod\n")); err != nil {
+		t.Fatal(err)
+	}
+	if origin, ok := p.Explain("name"); ok {
+		t.Errorf("name is not set by the content: %v", origin)
+	}
+	if origin, ok := p.Explain("mode"); !ok || origin.Source != SourceFile {
+		t.Errorf("mode: %+v, %v", origin, ok)
+	}
+}
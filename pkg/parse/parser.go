@@ -3,8 +3,10 @@ package parse
 import (
 	"bytes"
 	"encoding/json"
+	"io"
 	"log"
 	"os"
+	"time"
 
 	"github.com/pelletier/go-toml/v2"
 	"gopkg.in/yaml.v3"
@@ -19,15 +21,33 @@ type parser struct {
 	anonymousFields []*parseField // 匿名嵌套结构体
 	encoder         MarshalFunc
 	decoder         UnmarshalFunc
+	envPrefix       string                      // APP => APP_REDIS_PORT
+	envSeparator    string                      // separator between prefix and idents
+	args            []string                    // command-line args, default os.Args[1:]
+	output          io.Writer                   // usage and errors of LoadCmd, default os.Stderr
+	sources         []Source                    // sources applied by Resolve
+	origins         map[string]Origin           // full ID => source that last set it
+	strict          bool                        // check files before decoding
+	missingKeys     map[string]*ValidationError // required keys missing from the files in strict mode
+	debounce        time.Duration               // delay of reloads after the last write
+	sliceMerge      MergeMode                   // merge of the slices loaded from sources
+	overlay         bool                        // Resolve and Load skip the default tags
 }
 
 type Parser interface {
 	InspectStruct(interface{}) error
-	Load(readCloser []byte) error     // load from reader
-	ImportFile(filePath string) error // import cfg from file
-	ExportFile(filePath string) error // export cfg to file
-	LoadEnv() error                   // load from env
-	LoadCmd() error                   // load from os.args
+	Load(readCloser []byte) error         // load from reader
+	ImportFile(filePath string) error     // import cfg from file
+	ImportFiles(patterns ...string) error // import files, globs and directories, e.g. conf.d
+	ExportFile(filePath string) error     // export cfg to file
+	ExportTemplate(filePath string) error // export cfg to a commented YAML or TOML template
+	LoadEnv() error                       // load from env, e.g. APP_REDIS_PORT for redis.port
+	LoadCmd() error                       // load from os.args, e.g. --redis.port=6379
+	Resolve(c any) error                  // load c from all the sources by priority
+	Explain(id string) (Origin, bool)     // source that last set the field, e.g. redis.port
+	ExplainAll(w io.Writer) error         // write the origins of all the fields as a table
+	Validate() error                      // check the valid tags, all violations are returned
+	JSONSchema(c any) ([]byte, error)     // JSON Schema of the struct c
 
 }
 
@@ -77,9 +97,10 @@ var (
 
 func newDefaultParse() *parser {
 	return &parser{
-		tagOpt:  NewDefaultTagOpt(),
-		logger:  log.New(os.Stdout, "", log.Llongfile),
-		encoder: JSONEncoder,
+		tagOpt:       NewDefaultTagOpt(),
+		logger:       log.New(os.Stdout, "", log.Llongfile),
+		encoder:      JSONEncoder,
+		envSeparator: "_",
 	}
 }
 
@@ -93,8 +114,9 @@ func NewParser(opts ...SetOpt) Parser {
 }
 func newParserWithOption(opt *TagOption) *parser {
 	return &parser{
-		tagOpt:  opt,
-		logger:  log.New(os.Stdout, "", log.Llongfile),
-		encoder: JSONEncoder,
+		tagOpt:       opt,
+		logger:       log.New(os.Stdout, "", log.Llongfile),
+		encoder:      JSONEncoder,
+		envSeparator: "_",
 	}
 }
@@ -35,7 +35,7 @@ func TestParserStruct(t *testing.T) {
 	if err := p.InspectStruct(&c); err != nil {
 		t.Fatal(err)
 	}
-	if err := p.ExportFile(cfgFilePathTmpl); err != nil {
+	if err := p.ExportTemplate(cfgFilePathTmpl); err != nil {
 		t.Fatal(err)
 	}
 	content, err := ioutil.ReadFile(cfgFilePathDev)
@@ -50,3 +50,15 @@ func TestParserStruct(t *testing.T) {
 	}
 	t.Log("success")
 }
+
+func TestResolveLocalConf(t *testing.T) {
+	for _, sources := range [][]Source{nil, {DefaultSource(), FileSource(cfgFilePathDev)}} {
+		c := conf.LocalConf{}
+		if err := NewParser(SetIdent(JSON), SetSources(sources...)).Resolve(&c); err != nil {
+			t.Fatalf("sources %v: %v", sources, err)
+		}
+		if c.AppName != "devDemo" || len(c.WhiteIP) == 0 {
+			t.Errorf("sources %v: %+v", sources, c)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package parse
+
+import "github.com/asppj/goload/internal/conv"
+
+// RegisterDecoder registers decode to parse the values of type T from
+// defaults, env, flags and files, see goload.RegisterDecoder.
+func RegisterDecoder[T any](decode func(s string) (T, error)) {
+	conv.RegisterDecoder(decode)
+}
+
+// RegisterEncoder registers encode to export the values of type T, see
+// goload.RegisterEncoder.
+func RegisterEncoder[T any](encode func(v T) (string, error)) {
+	conv.RegisterEncoder(encode)
+}
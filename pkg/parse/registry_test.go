@@ -0,0 +1,107 @@
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type logLevel int
+
+var logLevels = []string{"debug", "info", "warn", "error"}
+
+// amount is a decimal amount in cents, its func field is not supported
+// without the registered decoder.
+type amount struct {
+	cents int64
+	round func(float64) float64
+}
+
+func init() {
+	RegisterDecoder(func(s string) (logLevel, error) {
+		for i, name := range logLevels {
+			if strings.EqualFold(s, name) {
+				return logLevel(i), nil
+			}
+		}
+		return 0, fmt.Errorf("unknown level %q", s)
+	})
+	RegisterEncoder(func(l logLevel) (string, error) {
+		if int(l) >= len(logLevels) {
+			return "", errors.New("unknown level")
+		}
+		return logLevels[l], nil
+	})
+	RegisterDecoder(func(s string) (amount, error) {
+		units, cents, _ := strings.Cut(s, ".")
+		n, err := strconv.ParseInt(units+(cents + "00")[:2], 10, 64)
+		return amount{cents: n}, err
+	})
+	RegisterEncoder(func(a amount) (string, error) {
+		return fmt.Sprintf("%d.%02d", a.cents/100, a.cents%100), nil
+	})
+}
+
+type registryConf struct {
+	Level  logLevel   `yaml:"level" default:"info"`
+	Levels []logLevel `yaml:"levels" default:"warn,error"`
+	Price  amount     `yaml:"price" default:"9.99"`
+	Max    *amount    `yaml:"max"`
+}
+
+func TestRegisterDecoder(t *testing.T) {
+	if err := isSupportedType(reflect.TypeOf(registryConf{})); err != nil {
+		t.Fatalf("registered types should be supported: %v", err)
+	}
+	t.Setenv("APP_LEVELS", "debug")
+	c := registryConf{}
+	p := NewParser(SetEnvPrefix("APP"), SetArgs([]string{"--max=100.5"}))
+	if err := p.InspectStruct(&c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Level != 1 || c.Price.cents != 999 || c.Max != nil {
+		t.Errorf("defaults: %+v", c)
+	}
+	if err := p.LoadEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.LoadCmd(); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Levels) != 1 || c.Levels[0] != 0 || c.Max == nil || c.Max.cents != 10050 {
+		t.Errorf("env and flags: %+v", c)
+	}
+
+	path := writeFile(t, "conf.yaml", "level: ERROR\nprice: 12\n")
+	if err := p.ImportFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if c.Level != 3 || c.Price.cents != 1200 {
+		t.Errorf("file: %+v", c)
+	}
+	out := filepath.Join(t.TempDir(), "conf.yaml")
+	if err := p.ExportFile(out); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"level: error", "- debug", "price: \"12.00\"", "max: \"100.50\""} {
+		if !strings.Contains(string(content), s) {
+			t.Errorf("export should contain %v:\n%s", s, content)
+		}
+	}
+
+	type invalid struct {
+		Level logLevel `default:"trace"`
+	}
+	if err := NewParser().InspectStruct(&invalid{}); err == nil || !strings.Contains(err.Error(), "unknown level") {
+		t.Errorf("the error of the decoder should be returned: %v", err)
+	}
+}
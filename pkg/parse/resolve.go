@@ -0,0 +1,137 @@
+package parse
+
+import (
+	"fmt"
+	"sort"
+)
+
+// SourceKind is the kind of a source, a higher kind has a higher priority.
+type SourceKind int
+
+const (
+	SourceDefault SourceKind = iota // default tag
+	SourceFile                      // config file
+	SourceEnv                       // env
+	SourceCmd                       // command-line flags
+)
+
+func (k SourceKind) String() string {
+	switch k {
+	case SourceDefault:
+		return "default"
+	case SourceFile:
+		return "file"
+	case SourceEnv:
+		return "env"
+	case SourceCmd:
+		return "flag"
+	}
+	return fmt.Sprintf("SourceKind(%d)", int(k))
+}
+
+// Source 配置来源
+type Source struct {
+	Kind    SourceKind
+	Path    string // file path of SourceFile
+	Content []byte // content of SourceFile decoded instead of reading Path
+}
+
+// DefaultSource sets the default tags, Resolve always applies them first.
+func DefaultSource() Source {
+	return Source{Kind: SourceDefault}
+}
+
+// FileSource imports a file, a glob or a directory, see ImportFiles; files
+// are applied in the order given.
+func FileSource(path string) Source {
+	return Source{Kind: SourceFile, Path: path}
+}
+
+// ContentSource decodes content as a file, name is the file path reported by
+// Explain and its extension sets the format, see ImportFile.
+func ContentSource(name string, content []byte) Source {
+	return Source{Kind: SourceFile, Path: name, Content: content}
+}
+
+// EnvSource loads env, see LoadEnv.
+func EnvSource() Source {
+	return Source{Kind: SourceEnv}
+}
+
+// CmdSource loads command-line flags, see LoadCmd.
+func CmdSource() Source {
+	return Source{Kind: SourceCmd}
+}
+
+func (s Source) String() string {
+	if s.Kind == SourceFile && s.Path == "" && s.Content != nil {
+		return "content"
+	}
+	if s.Kind == SourceFile {
+		return s.Kind.String() + ":" + s.Path
+	}
+	return s.Kind.String()
+}
+
+// Resolve loads c from the sources set by SetSources with a fixed priority:
+//
+//	default tag < files < env < flags
+//
+// whatever order they are given in. Lower sources are applied first, so a
+// higher source is never clobbered by a lower one; files are applied in the
+// order given, the later file wins. The default tags are always applied,
+// with or without DefaultSource, unless SetOverlay is set; values already set
+// in c before Resolve are kept by the default tags but overridden by the
+// other sources. The result is checked by Validate.
+func (p *parser) Resolve(c any) error {
+	p.source = c
+	if _, err := p.sourceValue(); err != nil {
+		return err
+	}
+	return p.resolve(p.sources)
+}
+
+// resolve applies the default tags and the sources by priority to the saved
+// source, then validates it.
+func (p *parser) resolve(sources []Source) error {
+	var layers []Source
+	if !p.overlay {
+		layers = append(layers, DefaultSource())
+	}
+	for _, source := range sources {
+		if source.Kind != SourceDefault {
+			layers = append(layers, source)
+		}
+	}
+	sort.SliceStable(layers, func(i, j int) bool {
+		return layers[i].Kind < layers[j].Kind
+	})
+	p.origins, p.missingKeys = nil, nil
+	for _, source := range layers {
+		if err := p.loadSource(source); err != nil {
+			return fmt.Errorf("error loading source %v: %w", source, err)
+		}
+	}
+	if err := p.checkMissingKeys(); err != nil {
+		return err
+	}
+	return p.Validate()
+}
+
+// loadSource applies a single source to the source struct.
+func (p *parser) loadSource(source Source) error {
+	switch source.Kind {
+	case SourceDefault:
+		return p.InspectStruct(p.source)
+	case SourceFile:
+		if source.Content != nil {
+			return p.loadContent(source.Path, source.Content)
+		}
+		return p.ImportFiles(source.Path)
+	case SourceEnv:
+		return p.LoadEnv()
+	case SourceCmd:
+		return p.LoadCmd()
+	}
+	return fmt.Errorf("unknown source kind %v", source.Kind)
+}
@@ -0,0 +1,105 @@
+package parse
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type resolveConf struct {
+	Name  string    `yaml:"name" default:"app"`
+	Mode  string    `yaml:"mode" default:"dev"`
+	Level string    `yaml:"level" default:"info"`
+	Redis *envRedis `yaml:"redis"`
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestResolve(t *testing.T) {
+	base := writeFile(t, "base.yaml", "name: base\nmode: prod\nlevel: warn\nredis:\n  port: 1\n")
+	local := writeFile(t, "local.yaml", "mode: test\nredis:\n  port: 2\n")
+	t.Setenv("APP_LEVEL", "error")
+	t.Setenv("APP_REDIS_PORT", "3")
+
+	c := resolveConf{}
+	p := NewParser(SetIdent(YAML), SetEnvPrefix("APP"),
+		SetArgs([]string{"--redis.port=4"}),
+		// the order given does not change the priority.
+		SetSources(CmdSource(), EnvSource(), FileSource(base), FileSource(local), DefaultSource()),
+	)
+	if err := p.Resolve(&c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "base" || c.Mode != "test" || c.Level != "error" {
+		t.Errorf("conf: %+v", c)
+	}
+	if c.Redis.Host != "127.0.0.1" || c.Redis.Port != 4 {
+		t.Errorf("redis: %+v", *c.Redis)
+	}
+}
+
+func TestResolveDefaultOnly(t *testing.T) {
+	c := resolveConf{Mode: "prod"}
+	if err := NewParser(SetIdent(YAML)).Resolve(&c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "app" || c.Mode != "prod" || c.Redis.Port != 6379 {
+		t.Errorf("conf: %+v", c)
+	}
+	if err := NewParser().Resolve(c); err == nil {
+		t.Error("non pointer should fail")
+	}
+}
+
+type layerConf struct {
+	Name   string `yaml:"name" default:"app"`
+	Enable bool   `yaml:"enable" default:"true"`
+	Port   int    `yaml:"port" default:"80"`
+}
+
+func TestLoadLayers(t *testing.T) {
+	// the zero values of the content are not replaced by the default tags
+	c := layerConf{}
+	p := NewParser(SetIdent(YAML))
+	if err := p.InspectStruct(&c); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Load([]byte("enable: false\nport: 0\n")); err != nil {
+		t.Fatal(err)
+	}
+	if c != (layerConf{Name: "app"}) {
+		t.Errorf("conf: %+v", c)
+	}
+	if origin, _ := p.Explain("enable"); origin.Source != SourceFile {
+		t.Errorf("origin of enable: %+v", origin)
+	}
+
+	// without DefaultSource
+	c = layerConf{}
+	file := writeFile(t, "conf.yaml", "enable: false\n")
+	if err := NewParser(SetIdent(YAML), SetSources(FileSource(file))).Resolve(&c); err != nil {
+		t.Fatal(err)
+	}
+	if c != (layerConf{Name: "app", Port: 80}) {
+		t.Errorf("conf: %+v", c)
+	}
+}
+
+func TestResolveOverlay(t *testing.T) {
+	// the zero values of the struct are kept by an overlay
+	c := layerConf{Name: "base"}
+	file := writeFile(t, "conf.yaml", "port: 8080\n")
+	if err := NewParser(SetIdent(YAML), SetOverlay(true), SetSources(DefaultSource(), FileSource(file))).Resolve(&c); err != nil {
+		t.Fatal(err)
+	}
+	if c != (layerConf{Name: "base", Port: 8080}) {
+		t.Errorf("conf: %+v", c)
+	}
+}
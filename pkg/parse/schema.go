@@ -0,0 +1,265 @@
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/asppj/goload/internal/conv"
+	"github.com/samber/lo"
+)
+
+const schemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema generates the JSON Schema (draft 2020-12) of the struct c:
+// property names follow the ident tag, the desc tag becomes description, the
+// default tag a typed default, the option tag an enum and the valid tag
+// required, minimum, pattern...
+func (p *parser) JSONSchema(c any) ([]byte, error) {
+	rt := reflect.TypeOf(c)
+	for rt != nil && rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	if rt == nil || rt.Kind() != reflect.Struct {
+		return nil, errors.New("config variable must be a struct or a pointer to a struct")
+	}
+	schema, err := p.structSchema(rt, nil)
+	if err != nil {
+		return nil, err
+	}
+	schema["$schema"] = schemaDraft
+	schema["title"] = rt.Name()
+	return JSONEncoder(schema)
+}
+
+func (p *parser) structSchema(t reflect.Type, parent *parseField) (map[string]interface{}, error) {
+	fields, _, err := inspectField(reflect.New(t).Elem(), parent, p.tagOpt)
+	if err != nil {
+		return nil, err
+	}
+	properties := make(map[string]interface{}, len(fields))
+	required := []string{}
+	var inlined []*parseField
+	for _, field := range fields {
+		if !field.canSet || field.tagValue.Ident == "-" {
+			continue
+		}
+		if field.isInlined {
+			inlined = append(inlined, field)
+			continue
+		}
+		schema, err := p.fieldSchema(field)
+		if err != nil {
+			return nil, err
+		}
+		properties[field.tagValue.Ident] = schema
+		rules, _ := parseValidRules(field.tagValue.Valid)
+		for _, rule := range rules {
+			if rule.name == "required" {
+				required = append(required, field.tagValue.Ident)
+			}
+		}
+	}
+	for _, field := range inlined {
+		t := field.value.Type()
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		sub, err := p.structSchema(t, field)
+		if err != nil {
+			return nil, err
+		}
+		for ident, schema := range sub["properties"].(map[string]interface{}) {
+			if _, ok := properties[ident]; !ok {
+				properties[ident] = schema
+			}
+		}
+		subRequired, _ := sub["required"].([]string)
+		for _, ident := range subRequired {
+			if !lo.Contains(required, ident) && properties[ident] != nil {
+				required = append(required, ident)
+			}
+		}
+	}
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema, nil
+}
+
+func (p *parser) fieldSchema(field *parseField) (map[string]interface{}, error) {
+	schema, err := p.typeSchema(field.value.Type(), field)
+	if err != nil {
+		return nil, err
+	}
+	if desc := field.tagValue.Describe; desc != "" {
+		schema["description"] = desc
+	}
+	if field.tagValue.DefaultSet && field.tagValue.Default != "-" && (!field.isParent || isLiteral(field.tagValue.Default)) {
+		v, err := p.parseDefault(field)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing default value for %v: %v", field.fullID(), err)
+		}
+		schema["default"] = p.toGenericTag(v, field.tagValue)
+	}
+	rules, err := parseValidRules(field.tagValue.Valid)
+	if err != nil {
+		return nil, fmt.Errorf("invalid valid tag of %v: %w", field.fullID(), err)
+	}
+	for _, rule := range rules {
+		applyRuleSchema(schema, rule)
+	}
+	if option := field.tagValue.Option; option != "" {
+		opts, err := readAsCSV(option)
+		if err != nil {
+			return nil, fmt.Errorf("invalid option tag of %v: %v", field.fullID(), err)
+		}
+		applyOptionSchema(itemsSchema(schema), opts)
+	}
+	if items := itemsSchema(schema); field.tagValue.Unit == conv.UnitBytes && items["type"] == "integer" {
+		// sizes are exported in the human form, e.g. 10MiB
+		items["type"] = "string"
+	}
+	return schema, nil
+}
+
+func (p *parser) typeSchema(t reflect.Type, field *parseField) (map[string]interface{}, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if isTextUnmarshaler(t) || t == typeOfByteSlice || t == typeOfDuration {
+		return map[string]interface{}{"type": "string"}, nil
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}, nil
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}, nil
+	case reflect.Struct:
+		return p.structSchema(t, field)
+	case reflect.Slice, reflect.Array:
+		items, err := p.typeSchema(t.Elem(), field)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "array", "items": items}, nil
+	case reflect.Map:
+		values, err := p.typeSchema(t.Elem(), field)
+		if err != nil {
+			return nil, err
+		}
+		schema := map[string]interface{}{"type": "object", "additionalProperties": values}
+		if t.Key().Kind() != reflect.String {
+			schema["propertyNames"] = map[string]interface{}{"pattern": "^-?[0-9]+$"}
+		}
+		return schema, nil
+	case reflect.Interface:
+		return map[string]interface{}{}, nil
+	}
+	return nil, fmt.Errorf("type %v of %v is not supported", t, field.fullID())
+}
+
+// itemsSchema returns the schema of the elements of an array, rules of
+// strings apply to each element.
+func itemsSchema(schema map[string]interface{}) map[string]interface{} {
+	if items, ok := schema["items"].(map[string]interface{}); ok && schema["type"] == "array" {
+		return items
+	}
+	return schema
+}
+
+// sizeKeywords are the min and max keywords by the schema type.
+var sizeKeywords = map[interface{}][2]string{
+	"integer": {"minimum", "maximum"},
+	"number":  {"minimum", "maximum"},
+	"string":  {"minLength", "maxLength"},
+	"array":   {"minItems", "maxItems"},
+	"object":  {"minProperties", "maxProperties"},
+}
+
+// applyRuleSchema maps a rule of the valid tag to the keywords of the schema,
+// required is set by the parent.
+func applyRuleSchema(schema map[string]interface{}, rule validRule) {
+	switch rule.name {
+	case "option":
+		applyOptionSchema(itemsSchema(schema), strings.Split(rule.arg, "|"))
+	case "min", "max", "len":
+		keywords, ok := sizeKeywords[schema["type"]]
+		if !ok {
+			return
+		}
+		limit, _ := strconv.ParseFloat(rule.arg, 64)
+		if rule.name != "max" {
+			schema[keywords[0]] = limit
+		}
+		if rule.name != "min" {
+			schema[keywords[1]] = limit
+		}
+	case "regex":
+		itemsSchema(schema)["pattern"] = rule.arg
+	case "url":
+		itemsSchema(schema)["format"] = "uri"
+	case "ip":
+		itemsSchema(schema)["anyOf"] = []interface{}{
+			map[string]interface{}{"format": "ipv4"},
+			map[string]interface{}{"format": "ipv6"},
+		}
+	case "hostport":
+		itemsSchema(schema)["pattern"] = "^.*:[0-9]{1,5}$"
+	}
+}
+
+// applyOptionSchema sets the options as an enum, or as a pattern if any
+// option has the ** wildcard.
+func applyOptionSchema(schema map[string]interface{}, opts []string) {
+	wildcard := false
+	for _, opt := range opts {
+		wildcard = wildcard || strings.Contains(opt, "**")
+	}
+	if !wildcard {
+		enum := make([]interface{}, 0, len(opts))
+		for _, opt := range opts {
+			enum = append(enum, optionValue(schema["type"], opt))
+		}
+		schema["enum"] = enum
+		return
+	}
+	patterns := make([]string, 0, len(opts))
+	for _, opt := range opts {
+		parts := strings.Split(opt, "**")
+		for i, part := range parts {
+			parts[i] = regexp.QuoteMeta(part)
+		}
+		patterns = append(patterns, strings.Join(parts, ".*"))
+	}
+	schema["pattern"] = "^(" + strings.Join(patterns, "|") + ")$"
+}
+
+// optionValue returns the option typed as the schema type.
+func optionValue(typ interface{}, opt string) interface{} {
+	switch typ {
+	case "integer", "number":
+		if f, err := strconv.ParseFloat(opt, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(opt); err == nil {
+			return b
+		}
+	}
+	return opt
+}
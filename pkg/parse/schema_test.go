@@ -0,0 +1,71 @@
+package parse
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type schemaConf struct {
+	Mode    string                `json:"mode" default:"dev" option:"dev,prod" desc:"run mode"`
+	Port    int                   `json:"port" default:"8080" valid:"required,min(1),max(65535)"`
+	Hosts   []string              `json:"hosts" default:"a,b" valid:"min(1),ip"`
+	Output  []string              `json:"output" option:"stdio,file://**"`
+	Redis   *envRedis             `json:"redis" desc:"redis"`
+	Loggers map[string]schemaItem `json:"loggers" default:"app"`
+	Codes   map[int]bool          `json:"codes"`
+	Name    string                `json:"name,omitempty" valid:"regex(^[a-z]+$),len(4)"`
+	Ignored string                `json:"-"`
+	private string
+}
+
+type schemaItem struct {
+	Level string `json:"level" default:"debug"`
+}
+
+func TestJSONSchema(t *testing.T) {
+	p := NewParser(SetIdent(JSON))
+	content, err := p.JSONSchema(&schemaConf{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(content, &got); err != nil {
+		t.Fatal(err)
+	}
+	var want map[string]interface{}
+	if err := json.Unmarshal([]byte(`{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "title": "schemaConf",
+  "type": "object",
+  "additionalProperties": false,
+  "required": ["port"],
+  "properties": {
+    "mode": {"type": "string", "description": "run mode", "default": "dev", "enum": ["dev", "prod"]},
+    "port": {"type": "integer", "default": 8080, "minimum": 1, "maximum": 65535},
+    "hosts": {"type": "array", "default": ["a", "b"], "minItems": 1,
+      "items": {"type": "string", "anyOf": [{"format": "ipv4"}, {"format": "ipv6"}]}},
+    "output": {"type": "array", "items": {"type": "string", "pattern": "^(stdio|file://.*)$"}},
+    "redis": {"type": "object", "description": "redis", "additionalProperties": false,
+      "properties": {
+        "host": {"type": "string", "default": "127.0.0.1"},
+        "port": {"type": "integer", "default": 6379}
+      }},
+    "loggers": {"type": "object", "default": {"app": {"level": "debug"}},
+      "additionalProperties": {"type": "object", "additionalProperties": false,
+        "properties": {"level": {"type": "string", "default": "debug"}}}},
+    "codes": {"type": "object", "additionalProperties": {"type": "boolean"},
+      "propertyNames": {"pattern": "^-?[0-9]+$"}},
+    "name": {"type": "string", "pattern": "^[a-z]+$", "minLength": 4, "maxLength": 4}
+  }
+}`), &want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("schema:\n%s", content)
+	}
+
+	if _, err := p.JSONSchema(1); err == nil {
+		t.Error("non struct should fail")
+	}
+}
@@ -0,0 +1,338 @@
+package parse
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/asppj/goload/internal/conv"
+)
+
+// checkStrict checks the content of a file against the source struct before
+// decoding: unknown keys and type mismatches are returned as ValidationErrors
+// with their position in the file. The required keys without default missing
+// from the file are only saved, another file may set them, see
+// checkMissingKeys.
+func (p *parser) checkStrict(content []byte, path string, f fileFormat) error {
+	rv, err := p.sourceValue()
+	if err != nil {
+		return err
+	}
+	var doc map[string]interface{}
+	if err := f.decoder(content, &doc); err != nil {
+		return err
+	}
+	index, err := documentIndex(content, f.name)
+	if err != nil {
+		index = nil // positions are only a hint
+	}
+	c := &strictChecker{p: p, path: path, index: index}
+	c.check(cleanUpYAML(doc), rv.Type(), nil)
+	if len(c.errs) > 0 {
+		return c.errs
+	}
+	if p.missingKeys == nil {
+		p.missingKeys = make(map[string]*ValidationError)
+	}
+	for _, err := range c.missing {
+		if _, ok := p.missingKeys[err.Field]; !ok {
+			p.missingKeys[err.Field] = err
+		}
+	}
+	return nil
+}
+
+// checkMissingKeys returns the required keys missing from the files of the
+// strict mode that no source set, once all the sources are applied.
+func (p *parser) checkMissingKeys() error {
+	var errs ValidationErrors
+	for id, err := range p.missingKeys {
+		if !p.isSet(id) {
+			errs = append(errs, err)
+		}
+	}
+	p.missingKeys = nil
+	if len(errs) == 0 {
+		return nil
+	}
+	sort.Slice(errs, func(i, j int) bool {
+		return errs[i].Field < errs[j].Field
+	})
+	return errs
+}
+
+// isSet reports whether a source set the field id, or a field of the struct
+// id.
+func (p *parser) isSet(id string) bool {
+	if _, ok := p.origins[id]; ok {
+		return true
+	}
+	for key := range p.origins {
+		if strings.HasPrefix(key, id+".") {
+			return true
+		}
+	}
+	return false
+}
+
+type strictChecker struct {
+	p       *parser
+	path    string // file path
+	index   map[string]docPosition
+	errs    ValidationErrors
+	missing ValidationErrors // required keys missing from the file
+}
+
+func (c *strictChecker) addError(parts []string, posParts []string, format string, args ...interface{}) {
+	c.errs = append(c.errs, c.newError(parts, posParts, format, args...))
+}
+
+// newError returns the error of the key parts at the position of posParts.
+func (c *strictChecker) newError(parts []string, posParts []string, format string, args ...interface{}) *ValidationError {
+	pos := c.index[strings.Join(posParts, ".")]
+	return &ValidationError{
+		Field:  strings.Join(parts, "."),
+		Rule:   "strict",
+		Msg:    fmt.Sprintf(format, args...),
+		File:   c.path,
+		Line:   pos.Line,
+		Column: pos.Column,
+	}
+}
+
+// check checks the decoded value v against the type t at the path parts.
+func (c *strictChecker) check(v interface{}, t reflect.Type, parts []string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if v == nil || t.Kind() == reflect.Interface {
+		return
+	}
+	if isTextUnmarshaler(t) || t == typeOfByteSlice {
+		switch v.(type) {
+		case map[string]interface{}, []interface{}:
+			c.addError(parts, parts, "expected %v, got %v", t, docType(v))
+		}
+		return
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			c.addError(parts, parts, "expected object, got %v", docType(v))
+			return
+		}
+		c.checkStruct(m, t, parts)
+	case reflect.Slice, reflect.Array:
+		s, ok := v.([]interface{})
+		if !ok {
+			c.addError(parts, parts, "expected array, got %v", docType(v))
+			return
+		}
+		for i, elem := range s {
+			c.check(elem, t.Elem(), append(append([]string(nil), parts...), strconv.Itoa(i)))
+		}
+	case reflect.Map:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			c.addError(parts, parts, "expected object, got %v", docType(v))
+			return
+		}
+		for _, key := range sortedKeys(m) {
+			keyParts := append(append([]string(nil), parts...), key)
+			if err := c.p.parseSimpleValue(reflect.New(t.Key()).Elem(), key); err != nil {
+				c.addError(keyParts, keyParts, "invalid key: %v", err)
+				continue
+			}
+			c.check(m[key], t.Elem(), keyParts)
+		}
+	default:
+		if err := checkScalar(v, t); err != nil {
+			c.addError(parts, parts, "%v", err)
+		}
+	}
+}
+
+func (c *strictChecker) checkStruct(m map[string]interface{}, t reflect.Type, parts []string) {
+	fields := make(map[string]*parseField, t.NumField())
+	types := make(map[string]reflect.Type, t.NumField())
+	c.structFields(t, &parseField{fullIDParts: parts}, fields, types)
+	for _, key := range sortedKeys(m) {
+		keyParts := append(append([]string(nil), parts...), key)
+		if _, ok := fields[key]; !ok {
+			c.addError(keyParts, keyParts, "unknown key")
+			continue
+		}
+		if tag := fields[key].tagValue; hasFormat(tag) {
+			c.checkFormat(m[key], types[key], keyParts, tag)
+			continue
+		}
+		c.check(m[key], types[key], keyParts)
+	}
+	idents := make([]string, 0, len(fields))
+	for ident := range fields {
+		idents = append(idents, ident)
+	}
+	sort.Strings(idents)
+	for _, ident := range idents {
+		field := fields[ident]
+		if _, ok := m[ident]; ok || field.tagValue.DefaultSet {
+			continue
+		}
+		rules, _ := parseValidRules(field.tagValue.Valid)
+		for _, rule := range rules {
+			if rule.name == "required" {
+				c.missing = append(c.missing, c.newError(field.fullIDParts, parts, "missing required key"))
+			}
+		}
+	}
+}
+
+// checkFormat checks the strings of a field with a layout or unit tag are
+// parsed by it.
+func (c *strictChecker) checkFormat(v interface{}, t reflect.Type, parts []string, tag TagValue) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch v := v.(type) {
+	case string:
+		if err := c.p.setValueByTag(reflect.New(t).Elem(), v, tag); err != nil {
+			c.addError(parts, parts, "%v", err)
+		}
+		return
+	case []interface{}:
+		if t.Kind() == reflect.Slice {
+			for i, elem := range v {
+				c.checkFormat(elem, t.Elem(), append(append([]string(nil), parts...), strconv.Itoa(i)), tag)
+			}
+			return
+		}
+	}
+	c.check(v, t, parts)
+}
+
+// structFields indexes the fields of the struct t by ident, the fields of
+// inlined structs included; the outer fields win.
+func (c *strictChecker) structFields(t reflect.Type, parent *parseField, fields map[string]*parseField, types map[string]reflect.Type) {
+	var inlined []*parseField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fieldParse := parseFromField(field, parent, c.p.tagOpt)
+		if fieldParse.tagValue.Ident == "-" {
+			continue
+		}
+		if fieldParse.isInlined {
+			fieldParse.value = reflect.Zero(field.Type)
+			inlined = append(inlined, fieldParse)
+			continue
+		}
+		fields[fieldParse.tagValue.Ident] = fieldParse
+		types[fieldParse.tagValue.Ident] = field.Type
+	}
+	for _, field := range inlined {
+		t := field.value.Type()
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		sub := make(map[string]*parseField)
+		subTypes := make(map[string]reflect.Type)
+		c.structFields(t, field, sub, subTypes)
+		for ident, f := range sub {
+			if _, ok := fields[ident]; !ok {
+				fields[ident], types[ident] = f, subTypes[ident]
+			}
+		}
+	}
+}
+
+// checkScalar checks the decoded scalar v can be set to the type t.
+func checkScalar(v interface{}, t reflect.Type) error {
+	if s, ok := v.(string); ok && t == typeOfDuration {
+		_, err := conv.ParseDuration(s)
+		return err
+	}
+	switch t.Kind() {
+	case reflect.String:
+		if _, ok := v.(string); ok {
+			return nil
+		}
+	case reflect.Bool:
+		if _, ok := v.(bool); ok {
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		f, ok := toFloat(v)
+		if !ok {
+			break
+		}
+		n := reflect.New(t).Elem()
+		switch t.Kind() {
+		case reflect.Float32, reflect.Float64:
+			if n.OverflowFloat(f) {
+				return fmt.Errorf("%v overflows %v", v, t)
+			}
+			return nil
+		}
+		if f != math.Trunc(f) {
+			return fmt.Errorf("expected %v, got %v", t, v)
+		}
+		if n.CanInt() && (f < math.MinInt64 || f > math.MaxInt64 || n.OverflowInt(int64(f))) ||
+			n.CanUint() && (f < 0 || f > math.MaxUint64 || n.OverflowUint(uint64(f))) {
+			return fmt.Errorf("%v overflows %v", v, t)
+		}
+		return nil
+	}
+	return fmt.Errorf("expected %v, got %v", t, docType(v))
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// docType returns the name of the type of a decoded value.
+func docType(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case int, int64, uint64, float64, json.Number:
+		return "number"
+	case time.Time:
+		return "time"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
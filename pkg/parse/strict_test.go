@@ -0,0 +1,118 @@
+package parse
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type strictConf struct {
+	AppName string            `yaml:"appName" json:"appName" valid:"required"`
+	Mode    string            `yaml:"mode" json:"mode" default:"dev" valid:"required"`
+	Port    int8              `yaml:"port" json:"port"`
+	Hosts   []string          `yaml:"hosts" json:"hosts"`
+	Redis   *strictRedis      `yaml:"redis" json:"redis"`
+	Codes   map[int]string    `yaml:"codes" json:"codes"`
+	Tags    map[string]string `yaml:"tags" json:"tags"`
+}
+
+type strictRedis struct {
+	Host string `yaml:"host" json:"host" valid:"required"`
+	Port int    `yaml:"port" json:"port"`
+}
+
+func TestStrictYAML(t *testing.T) {
+	file := writeFile(t, "conf.yaml", `appName: app
+port: 300
+hosts: localhost
+redis:
+  prot: 6379
+  port: "6379"
+codes:
+  a: x
+tags:
+  k: [1]
+`)
+	c := strictConf{}
+	p := NewParser(SetIdent(YAML), SetStrict(true))
+	if err := p.InspectStruct(&c); err != nil {
+		t.Fatal(err)
+	}
+	err := p.ImportFile(file)
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("%T: %v", err, err)
+	}
+	want := []string{
+		file + ":8:3: codes.a: invalid key: failed to parse 'a' into type int: strconv.ParseInt: parsing \"a\": invalid syntax",
+		file + ":3:1: hosts: expected array, got string",
+		file + ":2:1: port: 300 overflows int8",
+		file + ":6:3: redis.port: expected int, got string",
+		file + ":5:3: redis.prot: unknown key",
+		file + ":10:3: tags.k: expected string, got array",
+	}
+	got := make([]string, 0, len(errs))
+	for _, e := range errs {
+		got = append(got, e.Error())
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%v\nwant:\n%v", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if c.AppName != "" {
+		t.Error("source should not be touched")
+	}
+}
+
+func TestStrictJSON(t *testing.T) {
+	c := strictConf{}
+	p := NewParser(SetIdent(JSON), SetStrict(true))
+	if err := p.InspectStruct(&c); err != nil {
+		t.Fatal(err)
+	}
+	err := p.Load([]byte(`{"port": 1.5, "codes": {"1": "a"}, "redis": {"host": "h"}}`))
+	want := `1 validation errors: 1:2: port: expected int8, got 1.5`
+	var errs ValidationErrors
+	if !errors.As(err, &errs) || errs.Error() != want {
+		t.Errorf("got: %v\nwant: %v", err, want)
+	}
+	err = p.Load([]byte(`{"port": 1, "codes": {"1": "a"}, "redis": {"host": "h"}}`))
+	want = `1 validation errors: appName: missing required key`
+	if !errors.As(err, &errs) || errs.Error() != want {
+		t.Errorf("got: %v\nwant: %v", err, want)
+	}
+	if err := p.Load([]byte(`{"appName": "app", "port": 1, "codes": {"1": "a"}, "redis": {"host": "h"}}`)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestStrictLayers(t *testing.T) {
+	// the required keys are checked once all the files are merged
+	base := writeFile(t, "a.yaml", "appName: app\nredis:\n  host: h\n")
+	local := writeFile(t, "b.yaml", "redis:\n  port: 2\n")
+	c := strictConf{}
+	p := NewParser(SetIdent(YAML), SetStrict(true), SetSources(FileSource(base), FileSource(local)))
+	if err := p.Resolve(&c); err != nil {
+		t.Fatal(err)
+	}
+	if c.AppName != "app" || c.Redis.Host != "h" || c.Redis.Port != 2 {
+		t.Errorf("conf: %+v, redis: %+v", c, *c.Redis)
+	}
+	// the keys set by the previous sources are not set by these
+	err := p.Load([]byte("redis:\n  port: 3\n"))
+	var errs ValidationErrors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Errorf("reload should miss appName and redis.host: %v", err)
+	}
+	if origin, ok := p.Explain("appName"); ok {
+		t.Errorf("appName is not set by the reload: %v", origin)
+	}
+
+	t.Setenv("APP_APPNAME", "app")
+	c = strictConf{}
+	p = NewParser(SetIdent(YAML), SetStrict(true), SetEnvPrefix("APP"), SetSources(FileSource(local), EnvSource()))
+	err = p.Resolve(&c)
+	want := local + ":1:1: redis.host: missing required key"
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Error() != want {
+		t.Errorf("got: %v\nwant: %v", err, want)
+	}
+}
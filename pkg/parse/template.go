@@ -0,0 +1,289 @@
+package parse
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// ExportTemplate exports the source as a YAML or TOML template by the
+// extension of the file, each key is commented with the desc, option and
+// valid tags of its field. Call InspectStruct first to fill in the defaults.
+func (p *parser) ExportTemplate(filePath string) error {
+	rv, err := p.sourceValue()
+	if err != nil {
+		return err
+	}
+	var content []byte
+	switch ext := strings.ToLower(filepath.Ext(filePath)); ext {
+	case ".yaml", ".yml":
+		node, err := p.yamlNode(rv, nil)
+		if err != nil {
+			return err
+		}
+		if content, err = YAMLEncoder(node); err != nil {
+			return err
+		}
+	case ".toml":
+		buf := &bytes.Buffer{}
+		if err := p.writeTOMLTable(buf, rv, nil); err != nil {
+			return err
+		}
+		content = buf.Bytes()
+	default:
+		return fmt.Errorf("template format %q is not supported, use .yaml or .toml", ext)
+	}
+	return ioutil.WriteFile(filePath, content, fs.FileMode(0600))
+}
+
+// templateComment returns the comment of the field, one line per tag.
+func templateComment(field *parseField) string {
+	var lines []string
+	if desc := field.tagValue.Describe; desc != "" {
+		lines = append(lines, desc)
+	}
+	if option := field.tagValue.Option; option != "" {
+		lines = append(lines, "options: "+strings.Join(strings.Split(option, ","), ", "))
+	}
+	if valid := field.tagValue.Valid; valid != "" {
+		lines = append(lines, "valid: "+valid)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// templateFields returns the exported fields of the struct v, the fields of
+// inlined structs in their place; the outer fields win.
+func (p *parser) templateFields(v reflect.Value, parent *parseField) []*parseField {
+	var all []*parseField
+	outer := make(map[string]bool)
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fieldParse := parseFromField(field, parent, p.tagOpt)
+		if fieldParse.tagValue.Ident == "-" {
+			continue
+		}
+		fieldParse.value = v.Field(i)
+		all = append(all, fieldParse)
+		if !fieldParse.isInlined {
+			outer[fieldParse.tagValue.Ident] = true
+		}
+	}
+	var fields []*parseField
+	for _, field := range all {
+		if !field.isInlined {
+			fields = append(fields, field)
+			continue
+		}
+		value := indirectValue(field.value)
+		if !value.IsValid() {
+			value = reflect.New(field.value.Type().Elem()).Elem()
+		}
+		for _, sub := range p.templateFields(value, field) {
+			if !outer[sub.tagValue.Ident] {
+				fields = append(fields, sub)
+			}
+		}
+	}
+	return fields
+}
+
+// yamlNode converts v into a YAML node, the keys of struct fields are
+// commented by templateComment.
+func (p *parser) yamlNode(v reflect.Value, parent *parseField) (*yaml.Node, error) {
+	node := &yaml.Node{}
+	if parent != nil && hasFormat(parent.tagValue) {
+		return node, node.Encode(p.toGenericTag(v, parent.tagValue))
+	}
+	v = indirectValue(v)
+	if !v.IsValid() || !isTemplateTable(v) && !isSlice(v) && v.Kind() != reflect.Array {
+		return node, node.Encode(p.toGeneric(v))
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		node.Kind = yaml.MappingNode
+		for _, field := range p.templateFields(v, parent) {
+			value, err := p.yamlNode(field.value, field)
+			if err != nil {
+				return nil, err
+			}
+			key := &yaml.Node{Kind: yaml.ScalarNode, Value: field.tagValue.Ident, HeadComment: templateComment(field)}
+			node.Content = append(node.Content, key, value)
+		}
+	case reflect.Map:
+		node.Kind = yaml.MappingNode
+		for _, key := range sortedMapKeys(v) {
+			value, err := p.yamlNode(v.MapIndex(key), parent)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: fmt.Sprint(key)}, value)
+		}
+	default:
+		node.Kind = yaml.SequenceNode
+		for i := 0; i < v.Len(); i++ {
+			value, err := p.yamlNode(v.Index(i), parent)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, value)
+		}
+	}
+	return node, nil
+}
+
+// writeTOMLTable writes the fields of the struct v, values first and then the
+// tables, as TOML requires.
+func (p *parser) writeTOMLTable(buf *bytes.Buffer, v reflect.Value, parent *parseField) error {
+	fields := p.templateFields(v, parent)
+	var tables []*parseField
+	for _, field := range fields {
+		value := indirectValue(field.value)
+		if !value.IsValid() {
+			continue
+		}
+		if isTemplateTable(value) || isTemplateTables(value) {
+			tables = append(tables, field)
+			continue
+		}
+		writeTOMLComment(buf, templateComment(field))
+		line, err := tomlValue(field.tagValue.Ident, p.toGenericTag(value, field.tagValue))
+		if err != nil {
+			return fmt.Errorf("error encoding %v: %v", field.fullID(), err)
+		}
+		buf.WriteString(line)
+	}
+	for _, field := range tables {
+		value := indirectValue(field.value)
+		header := tomlKey(field.fullIDParts)
+		switch {
+		case value.Kind() == reflect.Struct:
+			buf.WriteString("\n")
+			writeTOMLComment(buf, templateComment(field))
+			fmt.Fprintf(buf, "[%v]\n", header)
+			if err := p.writeTOMLTable(buf, value, field); err != nil {
+				return err
+			}
+		case value.Kind() == reflect.Map:
+			buf.WriteString("\n")
+			writeTOMLComment(buf, templateComment(field))
+			if isTemplateTables(value) {
+				// map of structs, a table per key.
+				for _, key := range sortedMapKeys(value) {
+					elem := &parseField{fullIDParts: append(append([]string(nil), field.fullIDParts...), fmt.Sprint(key))}
+					fmt.Fprintf(buf, "[%v]\n", tomlKey(elem.fullIDParts))
+					if err := p.writeTOMLTable(buf, indirectValue(value.MapIndex(key)), elem); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			fmt.Fprintf(buf, "[%v]\n", header)
+			for _, key := range sortedMapKeys(value) {
+				line, err := tomlValue(fmt.Sprint(key), p.toGeneric(value.MapIndex(key)))
+				if err != nil {
+					return fmt.Errorf("error encoding %v: %v", field.fullID(), err)
+				}
+				buf.WriteString(line)
+			}
+		default:
+			// slice of structs, an array of tables.
+			buf.WriteString("\n")
+			writeTOMLComment(buf, templateComment(field))
+			for i := 0; i < value.Len(); i++ {
+				fmt.Fprintf(buf, "[[%v]]\n", header)
+				if err := p.writeTOMLTable(buf, indirectValue(value.Index(i)), field); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func writeTOMLComment(buf *bytes.Buffer, comment string) {
+	if comment == "" {
+		return
+	}
+	for _, line := range strings.Split(comment, "\n") {
+		buf.WriteString("# " + line + "\n")
+	}
+}
+
+var bareTOMLKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// tomlKey joins the parts of a dotted key, quoting the parts if needed.
+func tomlKey(parts []string) string {
+	quoted := make([]string, len(parts))
+	for i, part := range parts {
+		quoted[i] = part
+		if !bareTOMLKey.MatchString(part) {
+			quoted[i] = fmt.Sprintf("%q", part)
+		}
+	}
+	return strings.Join(quoted, ".")
+}
+
+// tomlValue encodes key = value by the TOML encoder.
+func tomlValue(key string, value interface{}) (string, error) {
+	if value == nil {
+		return "", nil // TOML has no null
+	}
+	content, err := toml.Marshal(map[string]interface{}{key: value})
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// isTemplateTable returns true if v is written as a table or a mapping.
+func isTemplateTable(v reflect.Value) bool {
+	if isTextUnmarshaler(v.Type()) {
+		return false
+	}
+	return v.Kind() == reflect.Struct || v.Kind() == reflect.Map
+}
+
+// isTemplateTables returns true if v is a non empty slice or map of structs,
+// written as an array of tables or a table per key.
+func isTemplateTables(v reflect.Value) bool {
+	if (!isSlice(v) && !isMap(v)) || v.Len() == 0 {
+		return false
+	}
+	elem := v.Type().Elem()
+	for elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	return elem.Kind() == reflect.Struct && !isTextUnmarshaler(elem)
+}
+
+// indirectValue dereferences pointers and interfaces, the zero Value is
+// returned for nil.
+func indirectValue(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func sortedMapKeys(v reflect.Value) []reflect.Value {
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+	})
+	return keys
+}
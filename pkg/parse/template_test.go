@@ -0,0 +1,128 @@
+package parse
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+type templateConf struct {
+	Mode    string                   `yaml:"mode" toml:"mode" default:"dev" option:"dev,prod" desc:"run mode"`
+	Port    int                      `yaml:"port" toml:"port" default:"8080" valid:"required,min(1)"`
+	Output  []string                 `yaml:"output" toml:"output" default:"stdio" option:"stdio,file://**"`
+	Redis   *templateRedis           `yaml:"redis" toml:"redis" desc:"redis"`
+	Loggers []templateLogger         `yaml:"loggers" toml:"loggers" default:"0,1" desc:"loggers"`
+	Tenants map[string]templateRedis `yaml:"tenants" toml:"tenants" default:"a"`
+	Labels  map[string]string        `yaml:"labels" toml:"labels"`
+}
+
+type templateRedis struct {
+	Host string `yaml:"host" toml:"host" default:"127.0.0.1" desc:"redis host"`
+}
+
+type templateLogger struct {
+	Name  string `yaml:"name" toml:"name" default:"app"`
+	Level string `yaml:"level" toml:"level" default:"debug" option:"debug,info"`
+}
+
+func TestExportTemplate(t *testing.T) {
+	dir := t.TempDir()
+	cases := []struct {
+		ident, file, want string
+		decode            func([]byte, interface{}) error
+	}{
+		{YAML, "conf.yaml", `# run mode
+# options: dev, prod
+mode: dev
+# valid: required,min(1)
+port: 8080
+# options: stdio, file://**
+output:
+  - stdio
+# redis
+redis:
+  # redis host
+  host: 127.0.0.1
+# loggers
+loggers:
+  - name: app
+    # options: debug, info
+    level: debug
+  - name: app
+    # options: debug, info
+    level: debug
+tenants:
+  a:
+    # redis host
+    host: 127.0.0.1
+labels:
+  env: prod
+`, yaml.Unmarshal},
+		{TOML, "conf.toml", `# run mode
+# options: dev, prod
+mode = 'dev'
+# valid: required,min(1)
+port = 8080
+# options: stdio, file://**
+output = ['stdio']
+
+# redis
+[redis]
+# redis host
+host = '127.0.0.1'
+
+# loggers
+[[loggers]]
+name = 'app'
+# options: debug, info
+level = 'debug'
+[[loggers]]
+name = 'app'
+# options: debug, info
+level = 'debug'
+
+[tenants.a]
+# redis host
+host = '127.0.0.1'
+
+[labels]
+env = 'prod'
+`, toml.Unmarshal},
+	}
+	for _, c := range cases {
+		conf := templateConf{Labels: map[string]string{"env": "prod"}}
+		p := NewParser(SetIdent(c.ident))
+		if err := p.InspectStruct(&conf); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, c.file)
+		if err := p.ExportTemplate(path); err != nil {
+			t.Fatal(err)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != c.want {
+			t.Errorf("%v:\n%s", c.file, content)
+		}
+		decoded := templateConf{}
+		if err := c.decode(content, &decoded); err != nil {
+			t.Fatalf("%v: %v", c.file, err)
+		}
+		if !reflect.DeepEqual(decoded, conf) {
+			t.Errorf("%v: decoded %+v", c.file, decoded)
+		}
+	}
+	p := NewParser()
+	if err := p.InspectStruct(&templateConf{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.ExportTemplate(filepath.Join(dir, "conf.json")); err == nil {
+		t.Error("json template should fail")
+	}
+}
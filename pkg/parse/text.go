@@ -0,0 +1,61 @@
+package parse
+
+import (
+	"reflect"
+
+	"github.com/asppj/goload/internal/conv"
+)
+
+// isRegistered reports whether a decoder is registered for t or the type t
+// points to.
+func isRegistered(t reflect.Type) bool {
+	if _, ok := conv.LookupDecoder(t); ok {
+		return true
+	}
+	if t.Kind() != reflect.Ptr {
+		return false
+	}
+	_, ok := conv.LookupDecoder(t.Elem())
+	return ok
+}
+
+// decodeText sets v by the decoder registered for its type or the type it
+// points to, ok is false without decoder.
+func decodeText(v reflect.Value, s string) (ok bool, err error) {
+	t := v.Type()
+	decode, ok := conv.LookupDecoder(t)
+	if !ok && t.Kind() == reflect.Ptr {
+		if decode, ok = conv.LookupDecoder(t.Elem()); ok {
+			v.Set(reflect.New(t.Elem()))
+			v = v.Elem()
+		}
+	}
+	if !ok {
+		return false, nil
+	}
+	decoded, err := decode(s)
+	if err != nil {
+		return true, parseError(s, t, err)
+	}
+	v.Set(decoded)
+	return true, nil
+}
+
+// encodeText returns the text of v by the encoder registered for its type or
+// the type it points to, ok is false without encoder.
+func encodeText(v reflect.Value) (text string, ok bool, err error) {
+	encode, ok := conv.LookupEncoder(v.Type())
+	if !ok && v.Kind() == reflect.Ptr {
+		if encode, ok = conv.LookupEncoder(v.Type().Elem()); ok {
+			if v.IsNil() {
+				return "", true, nil
+			}
+			v = v.Elem()
+		}
+	}
+	if !ok {
+		return "", false, nil
+	}
+	text, err = encode(v)
+	return text, true, err
+}
@@ -7,9 +7,11 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/asppj/goload/internal/conv"
 	"github.com/samber/lo"
 )
 
@@ -104,6 +106,9 @@ func parseFloat(v reflect.Value, s string) error {
 func (p *parser) parseSimpleValue(v reflect.Value, s string) error {
 	t := v.Type()
 
+	if ok, err := decodeText(v, s); ok {
+		return err
+	}
 	if t.Implements(typeOfTextUnmarshaler) {
 		// Is a reference, we must create element first.
 		v.Set(reflect.New(t.Elem()))
@@ -114,6 +119,15 @@ func (p *parser) parseSimpleValue(v reflect.Value, s string) error {
 		}
 		return nil
 	}
+	if v.CanAddr() && reflect.PointerTo(t).Implements(typeOfTextUnmarshaler) {
+		// Value receiver, unmarshal into the addressable value.
+		unmarshaler := v.Addr().Interface().(encoding.TextUnmarshaler)
+		if err := unmarshaler.UnmarshalText([]byte(s)); err != nil {
+			return fmt.Errorf("failed to unmarshal '%v' into type %v: %v",
+				s, t, err)
+		}
+		return nil
+	}
 
 	if t == typeOfByteSlice {
 		decoded, err := base64.StdEncoding.DecodeString(s)
@@ -124,6 +138,15 @@ func (p *parser) parseSimpleValue(v reflect.Value, s string) error {
 		return nil
 	}
 
+	if t == typeOfDuration {
+		d, err := conv.ParseDuration(s)
+		if err != nil {
+			return parseError(s, t, err)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
 	switch t.Kind() {
 	case reflect.String:
 		v.SetString(s)
@@ -136,12 +159,13 @@ func (p *parser) parseSimpleValue(v reflect.Value, s string) error {
 		v.Set(p.zeroType(nil, t))
 		// v.Set(reflect.Indirect(tv))
 	case reflect.Pointer:
-		fmt.Printf("point:%+v\n", t)
-		// tv := reflect.New(t)
-		// if err := p.setDefaultStruct(tv.Elem(), nil); err != nil {
-		// 	panic(err)
-		// }
 		tv := p.zeroType(nil, t)
+		if t.Elem().Kind() != reflect.Struct {
+			// *int, **string, *[]string...: parse into the pointed value.
+			if err := p.setValueByString(tv.Elem(), s); err != nil {
+				return err
+			}
+		}
 		v.Set(tv)
 
 	case reflect.Interface:
@@ -241,7 +265,7 @@ func (p *parser) zeroType(parent *parseField, ty reflect.Type) (tv reflect.Value
 	return tv
 }
 
-// parseSlice parses s to a slice and stores the slice in v.
+// parseMap parses s to the keys of a map and stores the map in v.
 func (p *parser) parseMap(parent *parseField, v reflect.Value, s string) error {
 	vals, err := readAsCSV(s)
 	if err != nil {
@@ -251,53 +275,98 @@ func (p *parser) parseMap(parent *parseField, v reflect.Value, s string) error {
 	// key type,value type
 	kt, vt := v.Type().Key(), v.Type().Elem()
 	for i := 0; i < len(vals); i++ {
-		ele := p.zeroType(parent, vt)
-		m.SetMapIndex(reflect.ValueOf(vals[i]).Convert(kt), ele)
-		// if err := parseSimpleValue(m.Index(i), vals[i]); err != nil {
-		// 	return err
-		// }
+		// key=value, or a key with the default value of the type
+		k, value, hasValue := strings.Cut(vals[i], "=")
+		key := reflect.New(kt).Elem()
+		if err := p.parseSimpleValue(key, k); err != nil {
+			return err
+		}
+		ele := reflect.New(vt).Elem()
+		ele.Set(p.zeroType(parent, vt))
+		if hasValue {
+			if elem := reflect.Indirect(ele); elem.Kind() == reflect.Struct && !isTextUnmarshaler(elem.Type()) {
+				return fmt.Errorf("value of key %q: structs are set by the json: or yaml: literal form", k)
+			}
+			if err := p.setValueByTag(ele, value, parent.tagValue); err != nil {
+				return fmt.Errorf("value of key %q: %v", k, err)
+			}
+		}
+		m.SetMapIndex(key, ele)
 	}
 	v.Set(m)
 	return nil
 }
 
-// parseMapToStruct converts a map[string]interface{} into a struct value.
-func (p *parser) parseMapToStruct(from, to reflect.Value, tagOption *TagOption) error {
-	opts, _, err := inspectField(to, nil, tagOption)
-	if err != nil {
-		// Here we panic since there is a problem in the config structure.
-		panic(fmt.Sprintf("error in config structure: "+
-			"invalid struct inside slice: %v", err))
-	}
-
-keys:
-	for _, key := range from.MapKeys() {
-		for _, opt := range opts {
-			if opt.fullID() == key.String() {
-				fromVal := from.MapIndex(key)
-				// All values should be interfaces (we have
-				// map[string]interface{}), so first uninterface the element.
-				if fromVal.Kind() == reflect.Interface {
-					fromVal = fromVal.Elem()
-				}
-
-				if err := p.setValue(opt.value, fromVal, tagOption); err != nil {
-					return fmt.Errorf("failed to set value in nested struct "+
-						"slice option '%v': %v", opt.fullID(), err)
-				}
-				continue keys
+// parseMapToStruct routes the keys of the decoded map from to the fields of
+// the struct to by their idents, see fromGeneric.
+func (p *parser) parseMapToStruct(from, to reflect.Value, parts []string) error {
+	fields := identFields(to.Type(), p.tagOpt.IdentTag)
+	keys := from.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	for _, key := range keys {
+		index, ok := fields[key.String()]
+		if !ok {
+			continue // unknown keys are checked by SetStrict
+		}
+		field, data, keyParts := fieldByIndex(to, index), from.MapIndex(key).Interface(), appendPart(parts, key.String())
+		if tag := p.fieldTag(to.Type(), index); hasFormat(tag) {
+			if err := p.fromGenericTag(field, data, keyParts, tag); err != nil {
+				return err
 			}
+			continue
+		}
+		if err := p.fromGeneric(field, data, keyParts); err != nil {
+			return err
 		}
-		// No option found for the key.
-		return fmt.Errorf("found no option with id '%v' in nested struct slice",
-			key.String())
 	}
-
 	return nil
 }
 
-// isKindOrPtrTo returns true is the given type is of the given kind or if it is
-// a pointer to the given kind.
+// identFields returns the indexes of the settable fields of the struct t by
+// ident, the fields of inlined structs included; the outer fields win.
+func identFields(t reflect.Type, identTag string) map[string][]int {
+	fields := make(map[string][]int)
+	var inlined [][]int
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if isInlined(field, identTag) {
+			inlined = append(inlined, []int{i})
+			continue
+		}
+		ident := identFromField(field, identTag)
+		if field.IsExported() && ident != "-" {
+			fields[ident] = []int{i}
+		}
+	}
+	for _, index := range inlined {
+		ft := t.Field(index[0]).Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		for ident, sub := range identFields(ft, identTag) {
+			if _, ok := fields[ident]; !ok {
+				fields[ident] = append(append([]int(nil), index...), sub...)
+			}
+		}
+	}
+	return fields
+}
+
+// fieldByIndex returns the nested field of the struct v, nil pointers to
+// inlined structs are allocated.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
 func isKindOrPtrTo(t reflect.Type, k reflect.Kind) bool {
 	if t.Kind() == k {
 		return true
@@ -326,7 +395,7 @@ func (p *parser) convertSlice(from, to reflect.Value, tagOption *TagOption) erro
 				inVal.Set(ptr)
 				inVal = ptr.Elem()
 			}
-			if err := p.parseMapToStruct(elem, inVal, tagOption); err != nil {
+			if err := p.parseMapToStruct(elem, inVal, nil); err != nil {
 				return fmt.Errorf("failed to convert to struct: %v", err)
 			}
 
@@ -385,7 +454,7 @@ func readAsCSV(val string) ([]string, error) {
 	return csvReader.Read()
 }
 func isSlice(v reflect.Value) bool {
-	return v.Kind() == reflect.Slice && v.Type() != typeOfByteSlice
+	return v.Kind() == reflect.Slice && v.Type() != typeOfByteSlice && !isTextUnmarshaler(v.Type())
 }
 func isMap(v reflect.Value) bool {
 	return v.Kind() == reflect.Map
@@ -411,11 +480,13 @@ func isZero(v reflect.Value) bool {
 		}
 		return z
 	case reflect.Ptr:
+		if v.IsNil() {
+			return true
+		}
 		return isZero(reflect.Indirect(v))
 	}
-	// Compare other types directly:
-	z := reflect.Zero(v.Type())
-	return v.Interface() == z.Interface()
+	// Compare other types directly, unexported fields can not be interfaced.
+	return v.IsZero()
 }
 
 var ( // Some type variables for comparison.
@@ -423,9 +494,19 @@ var ( // Some type variables for comparison.
 	typeOfByteSlice       = reflect.TypeOf([]byte{})
 )
 
+// isTextUnmarshaler returns true if t or *t implements encoding.TextUnmarshaler,
+// or if a decoder is registered for t: such values are not walked as structs
+// or slices of options.
+func isTextUnmarshaler(t reflect.Type) bool {
+	if isRegistered(t) {
+		return true
+	}
+	return t.Implements(typeOfTextUnmarshaler) || reflect.PointerTo(t).Implements(typeOfTextUnmarshaler)
+}
+
 // isSupportedType returns whether the type t is supported by goconfig for parsing.
 func isSupportedType(t reflect.Type) error {
-	if t.Implements(typeOfTextUnmarshaler) {
+	if isTextUnmarshaler(t) {
 		return nil
 	}
 
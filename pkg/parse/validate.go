@@ -0,0 +1,382 @@
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidationError is a value violating a rule of the valid tag, or a key of
+// a file rejected by the strict mode.
+type ValidationError struct {
+	Field  string // full ID, e.g. redis.port or l.0.name
+	Rule   string // e.g. min(1)
+	Msg    string
+	File   string // file path of the strict mode
+	Line   int    // line in the file, 0 if unknown
+	Column int    // column in the file, 0 if unknown
+}
+
+func (e *ValidationError) Error() string {
+	if e.Line > 0 {
+		// file:line:column, or line:column without file.
+		return strings.TrimPrefix(fmt.Sprintf("%v:%d:%d: %v: %v", e.File, e.Line, e.Column, e.Field, e.Msg), ":")
+	}
+	return fmt.Sprintf("%v: %v", e.Field, e.Msg)
+}
+
+// ValidationErrors is every violation found by Validate.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%d validation errors: %v", len(e), strings.Join(msgs, "; "))
+}
+
+// validRule is a rule of the valid tag, e.g. required, min(1) or option(a|b).
+type validRule struct {
+	name string
+	arg  string
+}
+
+func (r validRule) String() string {
+	if r.arg == "" {
+		return r.name
+	}
+	return r.name + "(" + r.arg + ")"
+}
+
+// validators checks a value by the rule name, see checkRule for the zero
+// values.
+var validators = map[string]func(arg string, v reflect.Value) error{
+	"required": func(arg string, v reflect.Value) error {
+		return nil // checked by Validate
+	},
+	"option": eachString(func(arg, s string) error {
+		for _, opt := range strings.Split(arg, "|") {
+			if s == opt {
+				return nil
+			}
+		}
+		return fmt.Errorf("%q is not one of [%v]", s, strings.ReplaceAll(arg, "|", ", "))
+	}),
+	"min": func(arg string, v reflect.Value) error {
+		return checkSize(arg, v, func(size, limit float64) bool { return size >= limit }, "less than")
+	},
+	"max": func(arg string, v reflect.Value) error {
+		return checkSize(arg, v, func(size, limit float64) bool { return size <= limit }, "greater than")
+	},
+	"len": func(arg string, v reflect.Value) error {
+		if _, ok := sizeOf(v, false); !ok {
+			return fmt.Errorf("len is not supported by type %v", v.Type())
+		}
+		return checkSize(arg, v, func(size, limit float64) bool { return size == limit }, "not")
+	},
+	"regex": eachString(func(arg, s string) error {
+		if !regexp.MustCompile(arg).MatchString(s) {
+			return fmt.Errorf("%q does not match %v", s, arg)
+		}
+		return nil
+	}),
+	"url": eachString(func(arg, s string) error {
+		u, err := url.Parse(s)
+		if err != nil {
+			return err
+		}
+		if u.Scheme == "" || (u.Host == "" && u.Path == "" && u.Opaque == "") {
+			return fmt.Errorf("%q is not an absolute url", s)
+		}
+		return nil
+	}),
+	"ip": eachString(func(arg, s string) error {
+		if net.ParseIP(s) == nil {
+			return fmt.Errorf("%q is not an ip", s)
+		}
+		return nil
+	}),
+	"hostport": eachString(func(arg, s string) error {
+		_, port, err := net.SplitHostPort(s)
+		if err != nil {
+			return err
+		}
+		if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+			return fmt.Errorf("%q has an invalid port", s)
+		}
+		return nil
+	}),
+}
+
+// Validate checks the source by the rules of the valid tag:
+//
+//	required      non-zero value
+//	option(a|b)   one of the values
+//	min(n)        minimum of a number, or minimum length of a string, slice or map
+//	max(n)        maximum of a number, or maximum length of a string, slice or map
+//	len(n)        length of a string, slice or map
+//	regex(expr)   string matching the regular expression
+//	url           absolute url
+//	ip            IPv4 or IPv6 address
+//	hostport      host:port
+//
+// Rules are separated by commas, e.g. `valid:"required,min(1)"`; rules of
+// strings are applied to each element of slices. The option tag is checked
+// too, see checkOption. Every violation is returned as ValidationErrors
+// instead of stopping at the first.
+func (p *parser) Validate() error {
+	rv, err := p.sourceValue()
+	if err != nil {
+		return err
+	}
+	var errs ValidationErrors
+	err = walkFields(rv, nil, p.tagOpt, func(field *parseField) error {
+		rules, err := parseValidRules(field.tagValue.Valid)
+		if err != nil {
+			return fmt.Errorf("invalid valid tag of %v: %w", field.fullID(), err)
+		}
+		for _, rule := range rules {
+			if err := checkRule(rule, field.value); err != nil {
+				errs = append(errs, &ValidationError{Field: field.fullID(), Rule: rule.String(), Msg: err.Error()})
+			}
+		}
+		if option := field.tagValue.Option; option != "" {
+			if err := checkOption(option, field.value); err != nil {
+				errs = append(errs, &ValidationError{Field: field.fullID(), Rule: "option:" + strconv.Quote(option), Msg: err.Error()})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// formatRules are the rules of the text of strings, an empty value is not
+// checked by them.
+var formatRules = map[string]bool{
+	"option": true, "regex": true, "url": true, "ip": true, "hostport": true,
+}
+
+// checkRule checks v by the rule. A zero value fails required and is skipped
+// by the formatRules, min, max and len are always checked, e.g. min(1) rejects
+// a zero port like the minimum of the JSON Schema; a nil pointer is an absent
+// value and only fails required.
+func checkRule(rule validRule, v reflect.Value) error {
+	if rule.name == "required" {
+		if isZero(v) {
+			return errors.New("is required")
+		}
+		return nil
+	}
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if formatRules[rule.name] && isZero(v) {
+		return nil
+	}
+	return validators[rule.name](rule.arg, v)
+}
+
+// parseValidRules parses the valid tag, commas in parentheses do not
+// separate rules, e.g. regex(^[a-z]{1,3}$).
+func parseValidRules(tag string) ([]validRule, error) {
+	var (
+		rules []validRule
+		depth int
+		start int
+	)
+	for i := 0; i <= len(tag); i++ {
+		if i < len(tag) {
+			switch tag[i] {
+			case '(':
+				depth++
+				continue
+			case ')':
+				depth--
+				continue
+			case ',':
+				if depth > 0 {
+					continue
+				}
+			default:
+				continue
+			}
+		}
+		raw := strings.TrimSpace(tag[start:i])
+		start = i + 1
+		if raw == "" {
+			continue
+		}
+		rule, err := parseValidRule(raw)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// parseValidRule parses a rule as name, name(arg) or name=arg.
+func parseValidRule(raw string) (validRule, error) {
+	rule := validRule{name: raw}
+	if i := strings.IndexAny(raw, "(="); i >= 0 {
+		rule.name, rule.arg = raw[:i], raw[i+1:]
+		if raw[i] == '(' {
+			if !strings.HasSuffix(rule.arg, ")") {
+				return rule, fmt.Errorf("unclosed rule %q", raw)
+			}
+			rule.arg = strings.TrimSuffix(rule.arg, ")")
+		}
+	}
+	if _, ok := validators[rule.name]; !ok {
+		return rule, fmt.Errorf("unknown rule %q", raw)
+	}
+	switch rule.name {
+	case "min", "max", "len":
+		if _, err := strconv.ParseFloat(rule.arg, 64); err != nil {
+			return rule, fmt.Errorf("invalid number of rule %q", raw)
+		}
+	case "regex":
+		if _, err := regexp.Compile(rule.arg); err != nil {
+			return rule, fmt.Errorf("invalid regex of rule %q: %v", raw, err)
+		}
+	}
+	return rule, nil
+}
+
+// eachString applies check to the string form of v, or of each element if v
+// is a slice.
+func eachString(check func(arg, s string) error) func(arg string, v reflect.Value) error {
+	return func(arg string, v reflect.Value) error {
+		if !isSlice(v) && v.Kind() != reflect.Array {
+			return check(arg, valueString(v))
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := check(arg, valueString(reflect.Indirect(v.Index(i)))); err != nil {
+				return fmt.Errorf("element %d: %w", i, err)
+			}
+		}
+		return nil
+	}
+}
+
+// valueString returns the text of a TextMarshaler, or the default format.
+func valueString(v reflect.Value) string {
+	if !v.IsValid() {
+		return ""
+	}
+	if text, ok := marshalText(v); ok {
+		return text
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// sizeOf returns a number, or the length of a string, slice or map; if
+// number is false, numbers are not supported.
+func sizeOf(v reflect.Value, number bool) (float64, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), number
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), number
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), number
+	}
+	return 0, false
+}
+
+func checkSize(arg string, v reflect.Value, ok func(size, limit float64) bool, op string) error {
+	limit, _ := strconv.ParseFloat(arg, 64)
+	size, supported := sizeOf(v, true)
+	if !supported {
+		return fmt.Errorf("size is not supported by type %v", v.Type())
+	}
+	if !ok(size, limit) {
+		if v.Kind() == reflect.String || v.Kind() == reflect.Slice || v.Kind() == reflect.Array || v.Kind() == reflect.Map {
+			return fmt.Errorf("length %v is %v %v", size, op, arg)
+		}
+		return fmt.Errorf("%v is %v %v", size, op, arg)
+	}
+	return nil
+}
+
+// checkOption checks the value, or each element of a slice, is one of the
+// CSV list of the option tag, ** matches any characters, e.g.
+// `option:"stdio,file://**"`. Zero values, structs and maps are not checked.
+func checkOption(option string, v reflect.Value) error {
+	opts, err := readAsCSV(option)
+	if err != nil {
+		return fmt.Errorf("invalid option tag %q: %v", option, err)
+	}
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if !isSlice(v) && v.Kind() != reflect.Array {
+		return matchOptions(opts, v)
+	}
+	for i := 0; i < v.Len(); i++ {
+		if err := matchOptions(opts, reflect.Indirect(v.Index(i))); err != nil {
+			return fmt.Errorf("element %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func matchOptions(opts []string, v reflect.Value) error {
+	if !v.IsValid() || isZero(v) {
+		return nil
+	}
+	if k := v.Kind(); !isTextUnmarshaler(v.Type()) && (k == reflect.Struct || k == reflect.Map || k == reflect.Slice) {
+		return nil
+	}
+	s := valueString(v)
+	for _, opt := range opts {
+		if matchOption(opt, s) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%q is not one of [%v]", s, strings.Join(opts, ", "))
+}
+
+// matchOption reports whether s matches the option, ** matches any characters.
+func matchOption(opt, s string) bool {
+	parts := strings.Split(opt, "**")
+	if len(parts) == 1 {
+		return opt == s
+	}
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(part):]
+	}
+	return len(s) >= len(last) && strings.HasSuffix(s, last)
+}
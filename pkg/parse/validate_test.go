@@ -0,0 +1,151 @@
+package parse
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type validConf struct {
+	AppName string            `yaml:"appName" valid:"required"`
+	Mode    string            `yaml:"mode" default:"dev" valid:"option(dev|prod)"`
+	Port    int               `yaml:"port" default:"80" valid:"min(1),max=65535"`
+	Code    string            `yaml:"code" default:"abcd" valid:"len(3),regex(^[a-z]{1,3}$)"`
+	Hosts   []string          `yaml:"hosts" default:"127.0.0.1,::1,localhost" valid:"min(1),ip"`
+	Addr    string            `yaml:"addr" default:"localhost:http" valid:"hostport"`
+	Home    string            `yaml:"home" default:"example.com" valid:"url"`
+	Redis   *validRedis       `yaml:"redis"`
+	Loggers []validRedis      `yaml:"loggers"`
+	Tenants map[string]string `yaml:"tenants" valid:"max(1)"`
+}
+
+type validRedis struct {
+	Host string `yaml:"host" valid:"required"`
+}
+
+func TestValidate(t *testing.T) {
+	c := validConf{
+		Loggers: []validRedis{{Host: "a"}, {}},
+		Tenants: map[string]string{"a": "1", "b": "2"},
+	}
+	p := NewParser(SetIdent(YAML))
+	if err := p.Resolve(&c); err == nil {
+		t.Fatal("should fail")
+	}
+	err := p.Validate()
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("%T: %v", err, err)
+	}
+	want := []string{
+		"appName: is required",
+		"code: length 4 is not 3",
+		"code: \"abcd\" does not match ^[a-z]{1,3}$",
+		"hosts: element 2: \"localhost\" is not an ip",
+		"addr: \"localhost:http\" has an invalid port",
+		"home: \"example.com\" is not an absolute url",
+		"redis.host: is required",
+		"loggers.1.host: is required",
+		"tenants: length 2 is greater than 1",
+	}
+	got := make([]string, 0, len(errs))
+	for _, e := range errs {
+		got = append(got, e.Error())
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%v\nwant:\n%v", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	c = validConf{AppName: "app", Code: "abc", Hosts: []string{"::1"}, Addr: ":80",
+		Home: "file:///etc/app", Redis: &validRedis{Host: "redis"}, Mode: "prod"}
+	p = NewParser(SetIdent(YAML))
+	if err := p.Resolve(&c); err != nil {
+		t.Errorf("valid config: %v", err)
+	}
+}
+
+type zeroConf struct {
+	Port   int      `yaml:"port" valid:"min(1)"`
+	Weight float64  `yaml:"weight" valid:"max(-1)"`
+	Code   string   `yaml:"code" valid:"len(2),regex(^[a-z]+$)"`
+	Hosts  []string `yaml:"hosts" valid:"min(1)"`
+	Addr   string   `yaml:"addr" valid:"hostport,url,ip"`
+}
+
+func TestValidateZero(t *testing.T) {
+	c := zeroConf{}
+	err := NewParser(SetIdent(YAML)).Resolve(&c)
+	// the formats of empty strings are not checked
+	want := `4 validation errors: port: 0 is less than 1; weight: 0 is greater than -1; ` +
+		`code: length 0 is not 2; hosts: length 0 is less than 1`
+	if err == nil || err.Error() != want {
+		t.Errorf("got: %v\nwant: %v", err, want)
+	}
+	c = zeroConf{Port: 1, Weight: -1, Code: "ab", Hosts: []string{"a"}}
+	if err := NewParser(SetIdent(YAML)).Resolve(&c); err != nil {
+		t.Errorf("valid config: %v", err)
+	}
+}
+
+func TestParseValidRules(t *testing.T) {
+	rules, err := parseValidRules("required, regex(^a,b$) ,option(a|b),min=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 4 || rules[1].arg != "^a,b$" || rules[2].arg != "a|b" || rules[3].arg != "1" {
+		t.Errorf("rules: %+v", rules)
+	}
+	for _, tag := range []string{"unknown", "min(a)", "regex([)", "option(a"} {
+		if _, err := parseValidRules(tag); err == nil {
+			t.Errorf("%v should fail", tag)
+		}
+	}
+}
+
+type optionConf struct {
+	Mode    string            `yaml:"mode" default:"dev" option:"dev,prod"`
+	Output  []string          `yaml:"output" default:"stdio,file://" option:"stdio,file://**,es://**.log"`
+	Level   *string           `yaml:"level" option:"debug,info"`
+	Loggers map[string]string `yaml:"loggers" option:"default"`
+}
+
+func TestValidateOption(t *testing.T) {
+	c := optionConf{}
+	p := NewParser(SetIdent(YAML))
+	if err := p.Resolve(&c); err != nil {
+		t.Fatal(err)
+	}
+
+	level := "warn"
+	c = optionConf{Mode: "test", Output: []string{"file:///var/log", "es://a.log", "es://a.txt"}, Level: &level,
+		Loggers: map[string]string{"a": "b"}}
+	err := NewParser(SetIdent(YAML)).Resolve(&c)
+	want := `3 validation errors: mode: "test" is not one of [dev, prod]; ` +
+		`output: element 2: "es://a.txt" is not one of [stdio, file://**, es://**.log]; ` +
+		`level: "warn" is not one of [debug, info]`
+	if err == nil || err.Error() != want {
+		t.Errorf("got: %v\nwant: %v", err, want)
+	}
+}
+
+func TestMatchOption(t *testing.T) {
+	cases := []struct {
+		opt, s string
+		match  bool
+	}{
+		{"dev", "dev", true},
+		{"dev", "prod", false},
+		{"file://**", "file://", true},
+		{"file://**", "file:///var/log", true},
+		{"file://**", "es://", false},
+		{"**", "", true},
+		{"a**b**c", "aXbYc", true},
+		{"a**b**c", "ac", false},
+		{"a**a", "a", false},
+	}
+	for _, c := range cases {
+		if got := matchOption(c.opt, c.s); got != c.match {
+			t.Errorf("matchOption(%q, %q) = %v", c.opt, c.s, got)
+		}
+	}
+}
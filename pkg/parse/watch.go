@@ -0,0 +1,245 @@
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const defaultDebounce = 100 * time.Millisecond
+
+// Watcher reloads a config file into T on change, see Watch.
+type Watcher[T any] struct {
+	filePath string
+	opts     []SetOpt
+	debounce time.Duration
+	current  atomic.Value // *T
+	reloadMu sync.Mutex   // serializes reloads
+	notifyMu sync.Mutex   // serializes notifications in the order of the reloads
+	timer    *time.Timer
+	timerMu  sync.Mutex
+	watcher  *fsnotify.Watcher
+	errors   chan error
+	done     chan struct{}
+	once     sync.Once
+	tagOpt   *TagOption
+	subMu    sync.RWMutex
+	subs     map[int]*subscription
+	nextSub  int
+}
+
+type subscription struct {
+	path string
+	fn   func(Change)
+}
+
+// Watch loads the file into a new T, then watches it and reloads it on
+// change: writes are debounced, the file is decoded into a fresh T with the
+// default tags applied and validated, and only then T is published
+// atomically. A failed reload keeps the previous config and is sent to Errors.
+// The opts are applied to the parser of every reload, e.g. SetIdent(YAML).
+func Watch[T any](filePath string, opts ...SetOpt) (*Watcher[T], error) {
+	p := newDefaultParse()
+	for _, opt := range opts {
+		opt(p)
+	}
+	w := &Watcher[T]{
+		filePath: filepath.Clean(filePath),
+		opts:     opts,
+		debounce: p.debounce,
+		errors:   make(chan error, 16),
+		done:     make(chan struct{}),
+		tagOpt:   p.tagOpt,
+		subs:     make(map[int]*subscription),
+	}
+	if w.debounce <= 0 {
+		w.debounce = defaultDebounce
+	}
+	if err := w.Reload(); err != nil {
+		return nil, err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	// Watch the directory, editors replace the file by renaming.
+	if err := watcher.Add(filepath.Dir(w.filePath)); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	w.watcher = watcher
+	go w.run()
+	return w, nil
+}
+
+// Get returns the current config, it must not be modified.
+func (w *Watcher[T]) Get() *T {
+	return w.current.Load().(*T)
+}
+
+// Errors returns the errors of the reloads, errors are dropped if they are
+// not received.
+func (w *Watcher[T]) Errors() <-chan error {
+	return w.errors
+}
+
+// Subscribe calls fn with every field changed by a successful reload whose
+// full ID is path or under it, e.g. redis for redis.host; an empty path
+// subscribes to all the fields. Unlike Diff, slices and maps are a single
+// change of the field with the old and new values, e.g. hosts and not
+// hosts.0. fn is called after the new config is published, in the order of
+// the reloads; it must not call Reload. The returned func cancels the
+// subscription.
+func (w *Watcher[T]) Subscribe(path string, fn func(Change)) (cancel func()) {
+	w.subMu.Lock()
+	defer w.subMu.Unlock()
+	id := w.nextSub
+	w.nextSub++
+	w.subs[id] = &subscription{path: path, fn: fn}
+	return func() {
+		w.subMu.Lock()
+		defer w.subMu.Unlock()
+		delete(w.subs, id)
+	}
+}
+
+// Reload loads the file into a fresh T and publishes it, the previous config
+// is kept on error. Subscribers are notified after publishing.
+func (w *Watcher[T]) Reload() error {
+	w.reloadMu.Lock()
+	changes, err := w.reload()
+	if err != nil {
+		w.reloadMu.Unlock()
+		return err
+	}
+	// notifyMu is locked before the next reload can publish, so the changes
+	// are delivered in the order of the reloads.
+	w.notifyMu.Lock()
+	defer w.notifyMu.Unlock()
+	w.reloadMu.Unlock()
+	w.notify(changes)
+	return nil
+}
+
+// reload loads and publishes a new config, reloadMu must be locked.
+func (w *Watcher[T]) reload() (Changes, error) {
+	c := new(T)
+	opts := append(append([]SetOpt(nil), w.opts...), SetSources(DefaultSource(), FileSource(w.filePath)))
+	p := NewParser(opts...)
+	if err := p.Resolve(c); err != nil {
+		return nil, fmt.Errorf("error reloading %v: %w", w.filePath, err)
+	}
+	old, _ := w.current.Load().(*T)
+	w.current.Store(c)
+	if old == nil {
+		return nil, nil
+	}
+	return diffFields(reflect.ValueOf(old).Elem(), reflect.ValueOf(c).Elem(), w.tagOpt), nil
+}
+
+// notify calls the subscribers of the changes, outside of the reload lock
+// but under notifyMu.
+func (w *Watcher[T]) notify(changes Changes) {
+	if len(changes) == 0 {
+		return
+	}
+	w.subMu.RLock()
+	subs := make([]*subscription, 0, len(w.subs))
+	ids := make([]int, 0, len(w.subs))
+	for id := range w.subs {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		subs = append(subs, w.subs[id])
+	}
+	w.subMu.RUnlock()
+	for _, sub := range subs {
+		for _, change := range changes {
+			if matchPath(sub.path, change.Path) {
+				sub.fn(change)
+			}
+		}
+	}
+}
+
+// matchPath reports whether the full ID is the path or under it.
+func matchPath(path, id string) bool {
+	return path == "" || id == path || strings.HasPrefix(id, path+".")
+}
+
+// Close stops watching the file.
+func (w *Watcher[T]) Close() error {
+	var err error
+	w.once.Do(func() {
+		close(w.done)
+		err = w.watcher.Close()
+		w.timerMu.Lock()
+		if w.timer != nil {
+			w.timer.Stop()
+		}
+		w.timerMu.Unlock()
+	})
+	return err
+}
+
+func (w *Watcher[T]) run() {
+	for {
+		select {
+		case <-w.done:
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != w.filePath ||
+				event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+				continue
+			}
+			w.schedule()
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			w.sendError(err)
+		}
+	}
+}
+
+// schedule reloads after the debounce delay, restarted by every write.
+func (w *Watcher[T]) schedule() {
+	w.timerMu.Lock()
+	defer w.timerMu.Unlock()
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.timer = time.AfterFunc(w.debounce, func() {
+		select {
+		case <-w.done:
+			return
+		default:
+		}
+		if err := w.Reload(); err != nil {
+			w.sendError(err)
+		}
+	})
+}
+
+func (w *Watcher[T]) sendError(err error) {
+	if errors.Is(err, fsnotify.ErrEventOverflow) {
+		// events lost, the file may have changed.
+		w.schedule()
+	}
+	select {
+	case w.errors <- err:
+	default:
+	}
+}
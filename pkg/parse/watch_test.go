@@ -0,0 +1,198 @@
+package parse
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type watchConf struct {
+	Name string `yaml:"name" default:"app"`
+	Port int    `yaml:"port" default:"80" valid:"min(1)"`
+}
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "conf.yaml")
+	if err := os.WriteFile(path, []byte("port: 8080\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	w, err := Watch[watchConf](path, SetIdent(YAML), SetDebounce(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if c := w.Get(); c.Name != "app" || c.Port != 8080 {
+		t.Fatalf("conf: %+v", *c)
+	}
+
+	// a valid write is published.
+	if err := os.WriteFile(path, []byte("name: demo\nport: 9090\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return w.Get().Port == 9090 })
+	if c := w.Get(); c.Name != "demo" {
+		t.Errorf("conf: %+v", *c)
+	}
+
+	// an invalid write keeps the previous config.
+	if err := os.WriteFile(path, []byte("port: -1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-w.Errors():
+		if err == nil {
+			t.Error("nil error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reload error")
+	}
+	if c := w.Get(); c.Name != "demo" || c.Port != 9090 {
+		t.Errorf("previous conf should be kept: %+v", *c)
+	}
+
+	if _, err := Watch[watchConf](filepath.Join(t.TempDir(), "missing.yaml"), SetIdent(YAML)); err == nil {
+		t.Error("missing file should fail")
+	}
+}
+
+// waitFor polls cond until it is true or the test times out.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+type subscribeConf struct {
+	Redis *envRedis `yaml:"redis"`
+	Log   struct {
+		Level string `yaml:"level" default:"info"`
+	} `yaml:"log"`
+	Hosts []string `yaml:"hosts"`
+}
+
+func TestWatcherSubscribe(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "conf.yaml")
+	if err := os.WriteFile(path, []byte("redis:\n  host: a\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// reload by hand only, the watcher would reload after an hour.
+	w, err := Watch[subscribeConf](path, SetIdent(YAML), SetDebounce(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	var redis, log, hosts, all []Change
+	w.Subscribe("redis", func(c Change) { redis = append(redis, c) })
+	w.Subscribe("hosts", func(c Change) { hosts = append(hosts, c) })
+	w.Subscribe("log", func(c Change) { log = append(log, c) })
+	cancel := w.Subscribe("", func(c Change) { all = append(all, c) })
+
+	if err := os.WriteFile(path, []byte("redis:\n  host: b\nhosts: [h1, h2]\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// callbacks run in the goroutine of Reload.
+	if err := w.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if len(redis) != 1 || redis[0] != (Change{Path: "redis.host", Kind: ChangeChanged, Old: "a", New: "b"}) {
+		t.Errorf("redis: %+v", redis)
+	}
+	if len(log) != 0 {
+		t.Errorf("log: %+v", log)
+	}
+	// a slice is a single change with the old and new slices
+	if len(hosts) != 1 || hosts[0].Path != "hosts" || hosts[0].Kind != ChangeChanged ||
+		hosts[0].Old.([]string) != nil || !reflect.DeepEqual(hosts[0].New, []string{"h1", "h2"}) {
+		t.Errorf("hosts: %+v", hosts)
+	}
+	if len(all) != 2 || all[1].Path != "hosts" {
+		t.Errorf("all: %+v", all)
+	}
+
+	cancel()
+	all = nil
+	if err := os.WriteFile(path, []byte("redis:\n  host: c\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 0 || len(redis) != 2 {
+		t.Errorf("canceled: %+v, redis: %+v", all, redis)
+	}
+	if len(hosts) != 2 || !reflect.DeepEqual(hosts[1].Old, []string{"h1", "h2"}) || hosts[1].New.([]string) != nil {
+		t.Errorf("hosts: %+v", hosts)
+	}
+}
+
+func TestWatcherNotifyOrder(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "conf.yaml")
+	if err := os.WriteFile(path, []byte("port: 1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// the caller's opts must not be written by the reloads
+	opts := make([]SetOpt, 2, 3)
+	opts[0], opts[1] = SetIdent(YAML), SetDebounce(time.Hour)
+	w, err := Watch[watchConf](path, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	var (
+		mu      sync.Mutex
+		changes []Change
+	)
+	w.Subscribe("port", func(c Change) {
+		time.Sleep(time.Millisecond) // a slow subscriber
+		mu.Lock()
+		defer mu.Unlock()
+		changes = append(changes, c)
+	})
+	var wg sync.WaitGroup
+	for i := 2; i < 20; i++ {
+		wg.Add(1)
+		go func(port int) {
+			defer wg.Done()
+			tmp := filepath.Join(dir, fmt.Sprintf("conf.%d.tmp", port))
+			if err := os.WriteFile(tmp, []byte(fmt.Sprintf("port: %d\n", port)), 0600); err != nil {
+				t.Error(err)
+				return
+			}
+			if err := os.Rename(tmp, path); err != nil {
+				t.Error(err)
+				return
+			}
+			if err := w.Reload(); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// every change follows the previous one, the last is the current config
+	old := 1
+	for _, c := range changes {
+		if c.Old != old {
+			t.Fatalf("change %+v does not follow port %v: %+v", c, old, changes)
+		}
+		old = c.New.(int)
+	}
+	if old != w.Get().Port {
+		t.Errorf("last change %v, current port %v", old, w.Get().Port)
+	}
+	if extra := opts[:cap(opts)][2]; extra != nil {
+		t.Error("opts of the caller modified")
+	}
+}
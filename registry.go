@@ -0,0 +1,19 @@
+package goload
+
+import "github.com/asppj/goload/internal/conv"
+
+// RegisterDecoder registers decode to parse the values of type T, and of
+// *T, from the default tags, and from env, flags and files with
+// pkg/parse. It is consulted before the built-in types, e.g. for log levels
+// or decimal amounts; a struct T is set as a value, not walked as a struct of
+// options.
+func RegisterDecoder[T any](decode func(s string) (T, error)) {
+	conv.RegisterDecoder(decode)
+}
+
+// RegisterEncoder registers encode to format the values of type T as text,
+// e.g. by the ExportFile of pkg/parse; it is consulted before
+// encoding.TextMarshaler.
+func RegisterEncoder[T any](encode func(v T) (string, error)) {
+	conv.RegisterEncoder(encode)
+}
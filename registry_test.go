@@ -0,0 +1,61 @@
+package goload
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+type logLevel int
+
+// window is a time window, its func field is not supported without the
+// registered decoder.
+type window struct {
+	from, to time.Duration
+	contains func(time.Duration) bool
+}
+
+func init() {
+	RegisterDecoder(func(s string) (logLevel, error) {
+		switch strings.ToLower(s) {
+		case "debug":
+			return 0, nil
+		case "info":
+			return 1, nil
+		}
+		return 0, fmt.Errorf("unknown level %q", s)
+	})
+	RegisterDecoder(func(s string) (window, error) {
+		from, to, _ := strings.Cut(s, "-")
+		f, err := ParseDuration(from)
+		if err != nil {
+			return window{}, err
+		}
+		t, err := ParseDuration(to)
+		return window{from: f, to: t}, err
+	})
+}
+
+type registryConf struct {
+	Level  logLevel   `default:"INFO"`
+	Levels []logLevel `default:"debug,info"`
+	Window *window    `default:"1h-2h"`
+}
+
+func TestRegisterDecoder(t *testing.T) {
+	c := registryConf{}
+	if err := LoadStruct(&c, "default"); err != nil {
+		t.Fatal(err)
+	}
+	if c.Level != 1 || len(c.Levels) != 2 || c.Levels[0] != 0 || c.Window.from != time.Hour || c.Window.to != 2*time.Hour {
+		t.Errorf("%+v", c)
+	}
+
+	type invalid struct {
+		Level logLevel `default:"trace"`
+	}
+	if err := LoadStruct(&invalid{}, "default"); err == nil || !strings.Contains(err.Error(), "unknown level") {
+		t.Errorf("the error of the decoder should be returned: %v", err)
+	}
+}
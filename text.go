@@ -0,0 +1,17 @@
+package goload
+
+import (
+	"reflect"
+
+	"github.com/asppj/goload/internal/conv"
+)
+
+// isValueType reports whether t is set from a single default value instead of
+// being walked as a struct or a slice: the registered types, time.Time,
+// net.IP, netip.Addr...
+func isValueType(t reflect.Type) bool {
+	if _, ok := conv.LookupDecoder(t); ok {
+		return true
+	}
+	return t.Implements(typeOfTextUnmarshaler) || reflect.PointerTo(t).Implements(typeOfTextUnmarshaler)
+}
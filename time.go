@@ -0,0 +1,24 @@
+package goload
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/asppj/goload/internal/conv"
+)
+
+// LayoutTag is the tag of the layout of time.Time fields, e.g.
+// `layout:"2006-01-02"` or `layout:"DateOnly"`, RFC3339 by default.
+const LayoutTag = "layout"
+
+var (
+	typeOfDuration = reflect.TypeOf(time.Duration(0))
+	typeOfTime     = reflect.TypeOf(time.Time{})
+)
+
+// ParseDuration parses a duration like time.ParseDuration, with the units d
+// (24h) and w (7d) in addition, e.g. 1w2d12h or 1.5d. A number without unit
+// is in nanoseconds, as a time.Duration decoded from a number.
+func ParseDuration(s string) (time.Duration, error) {
+	return conv.ParseDuration(s)
+}
@@ -0,0 +1,59 @@
+package goload
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	cases := map[string]time.Duration{
+		"5s":      5 * time.Second,
+		"1h30m":   90 * time.Minute,
+		"1d":      24 * time.Hour,
+		"1.5d":    36 * time.Hour,
+		"1w2d12h": 9*24*time.Hour + 12*time.Hour,
+		"-2w":     -14 * 24 * time.Hour,
+		"1000":    time.Microsecond,
+		"100ms1d": 24*time.Hour + 100*time.Millisecond,
+		"0":       0,
+		"+1d":     24 * time.Hour,
+		".5w":     84 * time.Hour,
+	}
+	for s, want := range cases {
+		d, err := ParseDuration(s)
+		if err != nil || d != want {
+			t.Errorf("ParseDuration(%q) = %v, %v; want %v", s, d, err, want)
+		}
+	}
+	for _, s := range []string{"", "-", "1x", "d", "1dd", "1.5.5d", "5d3", "x1d", "1d x", "1d-2h", "--1d"} {
+		if _, err := ParseDuration(s); err == nil {
+			t.Errorf("ParseDuration(%q) should fail", s)
+		}
+	}
+}
+
+type timeConf struct {
+	Timeout time.Duration `default:"1w"`
+	Start   time.Time     `default:"2024-01-02T03:04:05Z"`
+	Day     time.Time     `layout:"DateOnly" default:"2024-03-01"`
+	Days    []time.Time   `layout:"02/01/2006" default:"25/12/2024,01/01/2025"`
+}
+
+func TestLoadStructTime(t *testing.T) {
+	c := timeConf{}
+	if err := LoadStruct(&c, "default"); err != nil {
+		t.Fatal(err)
+	}
+	if c.Timeout != 7*24*time.Hour {
+		t.Errorf("timeout: %v", c.Timeout)
+	}
+	if !c.Start.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("start: %v", c.Start)
+	}
+	if !c.Day.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("day: %v", c.Day)
+	}
+	if len(c.Days) != 2 || !c.Days[1].Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("days: %v", c.Days)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/asppj/goload/internal/conv"
 	"github.com/samber/lo"
 )
 
@@ -20,11 +21,11 @@ var ( // Some type variables for comparison.
 
 // isSupportedType returns whether the type t is supported by goconfig for parsing.
 func isSupportedType(t reflect.Type) error {
-	if t.Implements(typeOfTextUnmarshaler) {
+	if isValueType(t) {
 		return nil
 	}
 
-	if t == typeOfByteSlice {
+	if t == typeOfByteSlice || t == typeOfDuration {
 		return nil
 	}
 
@@ -84,6 +85,14 @@ func readAsCSV(val string) ([]string, error) {
 func parseSimpleValue(v reflect.Value, s string) error {
 	t := v.Type()
 
+	if decode, ok := conv.LookupDecoder(t); ok {
+		decoded, err := decode(s)
+		if err != nil {
+			return parseError(s, t, err)
+		}
+		v.Set(decoded)
+		return nil
+	}
 	if t.Implements(typeOfTextUnmarshaler) {
 		// Is a reference, we must create element first.
 		v.Set(reflect.New(t.Elem()))
@@ -94,6 +103,15 @@ func parseSimpleValue(v reflect.Value, s string) error {
 		}
 		return nil
 	}
+	if v.CanAddr() && reflect.PointerTo(t).Implements(typeOfTextUnmarshaler) {
+		// Value receiver, unmarshal into the addressable value.
+		unmarshaler := v.Addr().Interface().(encoding.TextUnmarshaler)
+		if err := unmarshaler.UnmarshalText([]byte(s)); err != nil {
+			return fmt.Errorf("failed to unmarshal '%v' into type %v: %v",
+				s, t, err)
+		}
+		return nil
+	}
 
 	if t == typeOfByteSlice {
 		decoded, err := base64.StdEncoding.DecodeString(s)
@@ -104,6 +122,15 @@ func parseSimpleValue(v reflect.Value, s string) error {
 		return nil
 	}
 
+	if t == typeOfDuration {
+		d, err := ParseDuration(s)
+		if err != nil {
+			return parseError(s, t, err)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
 	switch t.Kind() {
 	case reflect.String:
 		v.SetString(s)